@@ -3,6 +3,7 @@ package dao
 import (
 	"fmt"
 	"math/rand"
+	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
@@ -21,7 +22,8 @@ type BgmApiAccessor struct {
 	randGen    *rand.Rand
 }
 
-func NewBgmApiAccessor() *BgmApiAccessor {
+// NewBgmApiAccessor creates the API client, sending requests through transport if it is not nil
+func NewBgmApiAccessor(transport http.RoundTripper) *BgmApiAccessor {
 	restyClinet := resty.New()
 	initialWaitTime := 2 * time.Second
 	restyClinet.SetRetryCount(5). // Retry 5 times
@@ -43,8 +45,12 @@ func NewBgmApiAccessor() *BgmApiAccessor {
 			},
 		)
 
+	httpClient := resty.New()
+	if transport != nil {
+		httpClient.SetTransport(transport)
+	}
 	return &BgmApiAccessor{
-		httpClient: resty.New(),
+		httpClient: httpClient,
 		randGen:    rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 }
@@ -76,6 +82,7 @@ func (apiClient *BgmApiAccessor) GetSubjects(tags []string, types []model.Subjec
 				Id:        subjectResult.Get("id").String(),
 				Type:      model.SubjectType(subjectResult.Get("type").Int()),
 				Name:      subjectResult.Get("name").String(),
+				NameCn:    subjectResult.Get("name_cn").String(),
 				AvgRating: float32(subjectResult.Get("score").Float()),
 			})
 		}
@@ -87,6 +94,108 @@ func (apiClient *BgmApiAccessor) GetSubjects(tags []string, types []model.Subjec
 	return subjects, nil
 }
 
+// GetSubjectRelations returns the relations from the subject to its related subjects
+// together with the related subjects themselves
+func (apiClient *BgmApiAccessor) GetSubjectRelations(sid string) ([]model.SubjectRelation, []model.Subject, error) {
+	log.Debug().Msgf("Sending get subject relations request with sid %s", sid)
+	respBody, resp, err := apiClient.get(&req.GetSubjectRelationsRequest{
+		Sid: sid,
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.IsError() {
+		return nil, nil, fmt.Errorf("GetSubjectRelationsRequest failed with status: %s and code: %d", resp.Status(), resp.StatusCode())
+	}
+
+	relations := make([]model.SubjectRelation, 0)
+	relatedSubjects := make([]model.Subject, 0)
+	for _, relatedResult := range respBody.Array() {
+		relationLabel := relatedResult.Get("relation").String()
+		relations = append(relations, model.SubjectRelation{
+			SubjectID:        sid,
+			RelatedSubjectID: relatedResult.Get("id").String(),
+			RelationType:     model.SubjectRelationTypeFromLabel(relationLabel),
+			Relation:         relationLabel,
+		})
+		relatedSubjects = append(relatedSubjects, model.Subject{
+			Id:     relatedResult.Get("id").String(),
+			Type:   model.SubjectType(relatedResult.Get("type").Int()),
+			Name:   relatedResult.Get("name").String(),
+			NameCn: relatedResult.Get("name_cn").String(),
+		})
+	}
+	return relations, relatedSubjects, nil
+}
+
+// GetSubjectPersons returns the staff of the subject together with the role each person took in it
+func (apiClient *BgmApiAccessor) GetSubjectPersons(sid string) ([]model.Person, []model.SubjectPerson, error) {
+	log.Debug().Msgf("Sending get subject persons request with sid %s", sid)
+	respBody, resp, err := apiClient.get(&req.GetSubjectPersonsRequest{
+		Sid: sid,
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.IsError() {
+		return nil, nil, fmt.Errorf("GetSubjectPersonsRequest failed with status: %s and code: %d", resp.Status(), resp.StatusCode())
+	}
+
+	persons := make([]model.Person, 0)
+	subjectPersons := make([]model.SubjectPerson, 0)
+	for _, personResult := range respBody.Array() {
+		career := make([]string, 0)
+		for _, careerResult := range personResult.Get("career").Array() {
+			career = append(career, careerResult.String())
+		}
+		persons = append(persons, model.Person{
+			Id:     personResult.Get("id").String(),
+			Name:   personResult.Get("name").String(),
+			Type:   personResult.Get("type").Int(),
+			Career: career,
+		})
+		subjectPersons = append(subjectPersons, model.SubjectPerson{
+			SubjectID: sid,
+			PersonID:  personResult.Get("id").String(),
+			Role:      personResult.Get("relation").String(),
+		})
+	}
+	return persons, subjectPersons, nil
+}
+
+// GetSubjectCharacters returns the characters of the subject together with the role each character plays in it
+func (apiClient *BgmApiAccessor) GetSubjectCharacters(sid string) ([]model.Character, []model.SubjectCharacter, error) {
+	log.Debug().Msgf("Sending get subject characters request with sid %s", sid)
+	respBody, resp, err := apiClient.get(&req.GetSubjectCharactersRequest{
+		Sid: sid,
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.IsError() {
+		return nil, nil, fmt.Errorf("GetSubjectCharactersRequest failed with status: %s and code: %d", resp.Status(), resp.StatusCode())
+	}
+
+	characters := make([]model.Character, 0)
+	subjectCharacters := make([]model.SubjectCharacter, 0)
+	for _, characterResult := range respBody.Array() {
+		characters = append(characters, model.Character{
+			Id:   characterResult.Get("id").String(),
+			Name: characterResult.Get("name").String(),
+			Type: characterResult.Get("type").Int(),
+		})
+		subjectCharacters = append(subjectCharacters, model.SubjectCharacter{
+			SubjectID:   sid,
+			CharacterID: characterResult.Get("id").String(),
+			Role:        characterResult.Get("relation").String(),
+		})
+	}
+	return characters, subjectCharacters, nil
+}
+
 func (apiClient *BgmApiAccessor) GetUser(uid string) (model.User, error) {
 	log.Debug().Msgf("Sending get user request with uid %s", uid)
 	getUserResult, resp, getUserErr := apiClient.get(&req.GetUserRequest{
@@ -144,13 +253,13 @@ func (apiClient *BgmApiAccessor) GetRecentCollections(uid string,
 	ctype model.CollectionType,
 	stype model.SubjectType,
 	collectionAcceptor func(gjson.Result) bool,
-	recentWindowInDays int) ([]model.Collection, error) {
+	since time.Time) ([]model.Collection, error) {
 	offset := 0
 	collections := make([]model.Collection, 0)
-	log.Debug().Msgf("Sending get recent collection request with uid %s, ctype %s, stype %s, recentWindowInDays %d", uid, ctype.String(), stype.String(), recentWindowInDays)
+	log.Debug().Msgf("Sending get recent collection request with uid %s, ctype %s, stype %s, since %s", uid, ctype.String(), stype.String(), since)
 
 	for {
-		log.Debug().Msgf("Sending get collection request with uid %s, ctype %s, stype %s, recentWindowInDays %d [offset %d]", uid, ctype.String(), stype.String(), recentWindowInDays, offset)
+		log.Debug().Msgf("Sending get collection request with uid %s, ctype %s, stype %s, since %s [offset %d]", uid, ctype.String(), stype.String(), since, offset)
 		newCollections, err := apiClient.getCollections(&req.GetPagedUserCollectionsRequest{
 			Uid:            uid,
 			CollectionType: ctype,
@@ -165,7 +274,7 @@ func (apiClient *BgmApiAccessor) GetRecentCollections(uid string,
 		log.Debug().Msgf("Found %d new collections", len(newCollections))
 		filteredNewCollections := make([]model.Collection, 0)
 		for _, newCollection := range newCollections {
-			if time.Since(newCollection.CollectedTime) < time.Duration(recentWindowInDays)*24*time.Hour {
+			if !newCollection.CollectedTime.Before(since) {
 				filteredNewCollections = append(filteredNewCollections, newCollection)
 			}
 		}
@@ -193,7 +302,8 @@ func (apiClient *BgmApiAccessor) getCollections(getPagedCollectionReq *req.GetPa
 	collectionResults := respBody.Get("data").Array()
 	for _, collectionResult := range collectionResults {
 		// filter on the raw response result instead of parsed collection
-		// because I don't want to add any field to model.Collection which I don't want to persist (like tags, etc)
+		// because I don't want to add any field to model.Collection which I don't want to persist (like subject tags, etc)
+		// the user's own comment and tags are kept aside in model.CollectionDetail
 		if collectionAcceptor(collectionResult) {
 			collectedTime, err := time.Parse(util.CollecttedTimeFormat, collectionResult.Get("updated_at").String())
 			if err != nil {
@@ -202,13 +312,26 @@ func (apiClient *BgmApiAccessor) getCollections(getPagedCollectionReq *req.GetPa
 				continue
 			}
 
+			tags := make([]string, 0)
+			for _, tag := range collectionResult.Get("tags").Array() {
+				tags = append(tags, tag.String())
+			}
+
 			newCollections = append(newCollections, model.Collection{
 				UserID:         getPagedCollectionReq.Uid,
 				SubjectType:    int64(getPagedCollectionReq.SubjectType),
 				SubjectID:      collectionResult.Get("subject_id").String(),
 				CollectionType: int64(getPagedCollectionReq.CollectionType),
-				CollectedTime:  collectedTime,
-				Rating:         int64(collectionResult.Get("rate").Int()),
+				CollectedTime:  collectedTime.UTC(),
+				// the API gives times with an offset, usually +08:00
+				CollectedTimeZone: collectedTime.Format("-07:00"),
+				Rating:            int64(collectionResult.Get("rate").Int()),
+				Detail: &model.CollectionDetail{
+					UserID:    getPagedCollectionReq.Uid,
+					SubjectID: collectionResult.Get("subject_id").String(),
+					Comment:   collectionResult.Get("comment").String(),
+					Tags:      tags,
+				},
 			})
 		}
 	}
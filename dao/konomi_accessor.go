@@ -1,6 +1,8 @@
 package dao
 
 import (
+	"time"
+
 	model "github.com/AlcEccentric/beck-mizuki/model"
 	jet "github.com/go-jet/jet/v2/postgres"
 )
@@ -8,8 +10,22 @@ import (
 type KonomiAccessor interface {
 	GetRowCount(table jet.Table) (int, error)
 	GetUser(uid string) (model.User, error)
+	GetActiveUserIds() ([]string, error)
+	GetCollectionsByUid(uid string) ([]model.Collection, error)
+	GetScrapedCollectionsByUid(uid string) ([]model.ScrapedCollection, error)
+	GetEvaluatedCandidateIds() ([]string, error)
 	GetUserIdsPaginated(offset, limit int) ([]string, error)
 	InsertUser(user model.User) error
+	UpdateUserQualityScore(uid string, qualityScore float64) error
+	MarkUserActive(uid string) error
+	UpdateUserEvaluation(evaluation model.UserEvaluation) error
+	GetWatchedCollectionCount(uid string) (int, error)
+	GetTierCounts() (map[int]int, error)
+	GetLatestTierStats() ([]model.TierStat, error)
+	BatchInsertTierStats(stats []model.TierStat, size int) error
+	UpdateUserBotFlag(uid string, botFlag string) error
+	AppendUserBotFlag(uid string, rule string, reason string) error
+	MarkUserInactive(uid string) (int, error)
 	BatchInsertUser(user []model.User, size int) error
 	DeleteUser(uid string) error
 	GetSubjectIdsPaginated(offset, limit int) ([]string, error)
@@ -18,5 +34,35 @@ type KonomiAccessor interface {
 	InsertCollection(collection model.Collection) error
 	BatchInsertCollection(collections []model.Collection, size int) error
 	DeleteCollectionByUid(uid string) error
+	BatchInsertCollectionDetail(details []model.CollectionDetail, size int) error
+	DeleteCollectionDetails(details []model.CollectionDetail) error
+	DeleteCollectionDetailByUid(uid string) error
+	GetBgmSubjectIds() ([]string, error)
+	GetRelationUnsyncedSubjectIds(syncedBefore time.Time) ([]string, error)
+	BatchInsertSubject(subjects []model.Subject, size int) error
+	BatchInsertArchivedSubject(subjects []model.Subject, archivedAt time.Time, size int) error
+	MarkSubjectRelationSynced(sid string, syncedAt time.Time) error
+	BatchUpdateFranchiseId(franchiseIds map[string]string, size int) error
+	GetSubjectRelations() ([]model.SubjectRelation, error)
+	BatchInsertSubjectRelation(relations []model.SubjectRelation, size int) error
+	GetScrapeWatermarks() (map[string]time.Time, error)
+	GetSubjectPlatforms() (map[string]int64, error)
+	BatchUpdateScrapeWatermark(watermarks map[string]time.Time, size int) error
+	ResetScrapeWatermarks() (int64, error)
+	BatchInsertScrapedCollection(collections []model.ScrapedCollection, size int) error
+	ShiftUnzonedScrapedCollections(lateBy time.Duration, zone string) (int64, error)
+	GetStaffUnsyncedSubjectIds(syncedBefore time.Time) ([]string, error)
+	MarkSubjectStaffSynced(sid string, syncedAt time.Time) error
+	BatchInsertPerson(persons []model.Person, size int) error
+	BatchInsertSubjectPerson(subjectPersons []model.SubjectPerson, size int) error
+	BatchInsertCharacter(characters []model.Character, size int) error
+	BatchInsertSubjectCharacter(subjectCharacters []model.SubjectCharacter, size int) error
+	BatchInsertUserFriend(friends []model.UserFriend, size int) error
+	BatchInsertCandidateSighting(sightings []model.CandidateSighting, size int) error
+	GetCandidateCount() (int, error)
+	GetCandidatesPaginated(offset, limit int) ([]model.Candidate, error)
+	DeleteCandidateSightings() error
+	GetCandidateEvaluations(uids []string) (map[string]model.CandidateEvaluation, error)
+	InsertCandidateEvaluation(evaluation model.CandidateEvaluation) error
 	Disconnect()
 }
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	. "github.com/go-jet/jet/v2/postgres"
 	_ "github.com/lib/pq"
@@ -139,6 +140,22 @@ func (accessor *KonomiCRAccessor) GetUser(uid string) (model.User, error) {
 	return model.FromBgmUser(rows[0]), nil
 }
 
+// GetActiveUserIds returns the users that did not fail their last activity check
+func (accessor *KonomiCRAccessor) GetActiveUserIds() ([]string, error) {
+	stmt := BgmUser.SELECT(BgmUser.ID).
+		FROM(BgmUser).
+		WHERE(BgmUser.Status.IS_NULL().OR(BgmUser.Status.EQ(String(string(model.ActiveUser)))))
+
+	var rows []string
+	err := stmt.Query(accessor.db, &rows)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
 func (accessor *KonomiCRAccessor) InsertUser(user model.User) error {
 	stmt := BgmUser.INSERT(BgmUser.AllColumns).
 		MODEL(user.ToBgmUser()).
@@ -157,6 +174,140 @@ func (accessor *KonomiCRAccessor) InsertUser(user model.User) error {
 	return nil
 }
 
+// UpdateUserQualityScore sets the score downstream training weighs the ratings of the user with, see helper.QualityModel
+func (accessor *KonomiCRAccessor) UpdateUserQualityScore(uid string, qualityScore float64) error {
+	stmt := BgmUser.UPDATE(BgmUser.QualityScore).
+		SET(Float(qualityScore)).
+		WHERE(BgmUser.ID.EQ(String(uid)))
+
+	_, err := stmt.Exec(accessor.db)
+
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdateUserBotFlag records why the user looks like a bot, or clears it if botFlag is empty
+func (accessor *KonomiCRAccessor) UpdateUserBotFlag(uid string, botFlag string) error {
+	var botFlagExp StringExpression = String(botFlag)
+	if botFlag == "" {
+		botFlagExp = StringExp(NULL)
+	}
+	stmt := BgmUser.UPDATE(BgmUser.BotFlag).
+		SET(botFlagExp).
+		WHERE(BgmUser.ID.EQ(String(uid)))
+
+	_, err := stmt.Exec(accessor.db)
+
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// AppendUserBotFlag adds the reason of a bot detector to the flag of the user, unless the detector already flagged them
+func (accessor *KonomiCRAccessor) AppendUserBotFlag(uid string, rule string, reason string) error {
+	botFlag := rule + ": " + reason
+	stmt := BgmUser.UPDATE(BgmUser.BotFlag).
+		SET(CASE().
+			WHEN(BgmUser.BotFlag.IS_NULL()).THEN(String(botFlag)).
+			ELSE(BgmUser.BotFlag.CONCAT(String("; " + botFlag)))).
+		WHERE(BgmUser.ID.EQ(String(uid)).
+			AND(BgmUser.BotFlag.IS_NULL().OR(BgmUser.BotFlag.NOT_LIKE(String("%" + rule + ":%")))))
+
+	_, err := stmt.Exec(accessor.db)
+
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (accessor *KonomiCRAccessor) UpdateUserEvaluation(evaluation model.UserEvaluation) error {
+	stmt := BgmUser.UPDATE(BgmUser.Tier, BgmUser.RawWatchedCount, BgmUser.FilteredWatchedCount, BgmUser.ActivityGap, BgmUser.LastEvaluatedAt).
+		SET(
+			Int(int64(evaluation.Tier)),
+			Int(int64(evaluation.RawWatchedCount)),
+			Int(int64(evaluation.FilteredWatchedCount)),
+			Float(evaluation.ActivityGap),
+			TimestampzT(evaluation.EvaluatedAt),
+		).
+		WHERE(BgmUser.ID.EQ(String(evaluation.UserID)))
+
+	_, err := stmt.Exec(accessor.db)
+
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetTierCounts returns the number of active users of each tier, users never evaluated are counted in model.UnevaluatedTier
+func (accessor *KonomiCRAccessor) GetTierCounts() (map[int]int, error) {
+	stmt := BgmUser.SELECT(
+		BgmUser.Tier.AS("tier_count.tier"),
+		COUNT(STAR).AS("tier_count.user_cnt"),
+	).
+		FROM(BgmUser).
+		WHERE(BgmUser.Status.IS_NULL().OR(BgmUser.Status.EQ(String(string(model.ActiveUser))))).
+		GROUP_BY(BgmUser.Tier)
+
+	var rows []struct {
+		Tier    *int64 `alias:"tier_count.tier"`
+		UserCnt int64  `alias:"tier_count.user_cnt"`
+	}
+	err := stmt.Query(accessor.db, &rows)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tierCounts := make(map[int]int, len(rows))
+	for _, row := range rows {
+		tier := model.UnevaluatedTier
+		if row.Tier != nil {
+			tier = int(*row.Tier)
+		}
+		tierCounts[tier] = int(row.UserCnt)
+	}
+	return tierCounts, nil
+}
+
+// MarkUserActive reactivates the user and resets the consecutive activity check failures
+func (accessor *KonomiCRAccessor) MarkUserActive(uid string) error {
+	stmt := BgmUser.UPDATE(BgmUser.Status, BgmUser.ConsecutiveFailures).
+		SET(String(string(model.ActiveUser)), Int(0)).
+		WHERE(BgmUser.ID.EQ(String(uid)))
+
+	_, err := stmt.Exec(accessor.db)
+
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// MarkUserInactive deactivates the user and returns the number of consecutive activity check failures including this one
+func (accessor *KonomiCRAccessor) MarkUserInactive(uid string) (int, error) {
+	stmt := BgmUser.UPDATE(BgmUser.Status, BgmUser.ConsecutiveFailures).
+		SET(String(string(model.InactiveUser)), IntExp(COALESCE(BgmUser.ConsecutiveFailures, Int(0))).ADD(Int(1))).
+		WHERE(BgmUser.ID.EQ(String(uid))).
+		RETURNING(BgmUser.ConsecutiveFailures)
+
+	var rows []jetmodel.BgmUser
+	err := stmt.Query(accessor.db, &rows)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(rows) == 0 || rows[0].ConsecutiveFailures == nil {
+		return 0, errors.New("user not found")
+	}
+	return int(*rows[0].ConsecutiveFailures), nil
+}
+
 func (accessor *KonomiCRAccessor) BatchInsertUser(users []model.User, batchSize int) error {
 
 	startIdx := 0
@@ -219,7 +370,14 @@ func (accessor *KonomiCRAccessor) BatchInsertCollection(collections []model.Coll
 		}
 		stmt := BgmUserCollection.INSERT(BgmUserCollection.AllColumns).
 			MODELS(model.ToBgmUserCollections(collections[startIdx:endIdx])).
-			ON_CONFLICT(BgmUserCollection.UserID, BgmUserCollection.SubjectID).DO_NOTHING()
+			ON_CONFLICT(BgmUserCollection.UserID, BgmUserCollection.SubjectID).
+			DO_UPDATE(SET(
+				// a subject moves between collection types (e.g. ToWatch -> Watched), so keep the latest state
+				BgmUserCollection.CollectionType.SET(BgmUserCollection.EXCLUDED.CollectionType),
+				BgmUserCollection.CollectedTime.SET(BgmUserCollection.EXCLUDED.CollectedTime),
+				BgmUserCollection.CollectedTimeZone.SET(BgmUserCollection.EXCLUDED.CollectedTimeZone),
+				BgmUserCollection.Rating.SET(BgmUserCollection.EXCLUDED.Rating),
+			))
 
 		_, err := stmt.Exec(accessor.db)
 
@@ -236,6 +394,39 @@ func (accessor *KonomiCRAccessor) BatchInsertCollection(collections []model.Coll
 	return nil
 }
 
+func (accessor *KonomiCRAccessor) GetCollectionsByUid(uid string) ([]model.Collection, error) {
+	stmt := BgmUserCollection.SELECT(BgmUserCollection.AllColumns).
+		FROM(BgmUserCollection).
+		WHERE(BgmUserCollection.UserID.EQ(String(uid)))
+
+	var rows []jetmodel.BgmUserCollection
+	err := stmt.Query(accessor.db, &rows)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return model.FromBgmUserCollections(rows), nil
+}
+
+func (accessor *KonomiCRAccessor) GetWatchedCollectionCount(uid string) (int, error) {
+	stmt := BgmUserCollection.SELECT(COUNT(STAR)).
+		FROM(BgmUserCollection).
+		WHERE(BgmUserCollection.UserID.EQ(String(uid)).
+			AND(BgmUserCollection.CollectionType.EQ(Int(int64(model.Watched)))))
+
+	var rows []struct {
+		Count int
+	}
+	err := stmt.Query(accessor.db, &rows)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return rows[0].Count, nil
+}
+
 func (accessor *KonomiCRAccessor) DeleteCollectionByUid(uid string) error {
 	stmt := BgmUserCollection.DELETE().
 		WHERE(BgmUserCollection.UserID.EQ(String(uid)))
@@ -246,3 +437,863 @@ func (accessor *KonomiCRAccessor) DeleteCollectionByUid(uid string) error {
 	}
 	return nil
 }
+
+func (accessor *KonomiCRAccessor) BatchInsertCollectionDetail(details []model.CollectionDetail, batchSize int) error {
+	startIdx := 0
+	errs := make([]error, 0)
+	for startIdx < len(details) {
+		endIdx := startIdx + batchSize
+		if endIdx > len(details) {
+			endIdx = len(details)
+		}
+		stmt := BgmUserCollectionDetail.INSERT(BgmUserCollectionDetail.AllColumns).
+			MODELS(model.ToBgmUserCollectionDetails(details[startIdx:endIdx])).
+			ON_CONFLICT(BgmUserCollectionDetail.UserID, BgmUserCollectionDetail.SubjectID).
+			DO_UPDATE(SET(
+				BgmUserCollectionDetail.Comment.SET(BgmUserCollectionDetail.EXCLUDED.Comment),
+				BgmUserCollectionDetail.Tags.SET(BgmUserCollectionDetail.EXCLUDED.Tags),
+			))
+
+		_, err := stmt.Exec(accessor.db)
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+		startIdx = endIdx
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+// DeleteCollectionDetails deletes the stored details of the same collections as the given details
+func (accessor *KonomiCRAccessor) DeleteCollectionDetails(details []model.CollectionDetail) error {
+	if len(details) == 0 {
+		return nil
+	}
+	subjectIdsByUser := make(map[string][]Expression)
+	for _, detail := range details {
+		subjectIdsByUser[detail.UserID] = append(subjectIdsByUser[detail.UserID], String(detail.SubjectID))
+	}
+	condition := Bool(false)
+	for uid, subjectIds := range subjectIdsByUser {
+		condition = condition.OR(BgmUserCollectionDetail.UserID.EQ(String(uid)).
+			AND(BgmUserCollectionDetail.SubjectID.IN(subjectIds...)))
+	}
+	stmt := BgmUserCollectionDetail.DELETE().
+		WHERE(condition)
+
+	_, err := stmt.Exec(accessor.db)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (accessor *KonomiCRAccessor) DeleteCollectionDetailByUid(uid string) error {
+	stmt := BgmUserCollectionDetail.DELETE().
+		WHERE(BgmUserCollectionDetail.UserID.EQ(String(uid)))
+
+	_, err := stmt.Exec(accessor.db)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (accessor *KonomiCRAccessor) GetBgmSubjectIds() ([]string, error) {
+	stmt := BgmSubject.SELECT(BgmSubject.ID).
+		FROM(BgmSubject)
+
+	var rows []string
+	err := stmt.Query(accessor.db, &rows)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// GetRelationUnsyncedSubjectIds returns ids of collected subjects whose relations were never synced or synced before syncedBefore
+func (accessor *KonomiCRAccessor) GetRelationUnsyncedSubjectIds(syncedBefore time.Time) ([]string, error) {
+	stmt := SELECT(DISTINCT(BgmUserCollection.SubjectID)).
+		FROM(BgmUserCollection.
+			LEFT_JOIN(BgmSubject, BgmSubject.ID.EQ(BgmUserCollection.SubjectID))).
+		WHERE(BgmSubject.RelationSyncedAt.IS_NULL().
+			OR(BgmSubject.RelationSyncedAt.LT(TimestampzT(syncedBefore))))
+
+	var rows []string
+	err := stmt.Query(accessor.db, &rows)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+func (accessor *KonomiCRAccessor) BatchInsertSubject(subjects []model.Subject, batchSize int) error {
+	startIdx := 0
+	errs := make([]error, 0)
+	for startIdx < len(subjects) {
+		endIdx := startIdx + batchSize
+		if endIdx > len(subjects) {
+			endIdx = len(subjects)
+		}
+		stmt := BgmSubject.INSERT(BgmSubject.ID, BgmSubject.Type, BgmSubject.Name, BgmSubject.NameCn).
+			MODELS(model.ToBgmSubjects(subjects[startIdx:endIdx])).
+			ON_CONFLICT(BgmSubject.ID).
+			DO_UPDATE(SET(
+				BgmSubject.Type.SET(BgmSubject.EXCLUDED.Type),
+				BgmSubject.Name.SET(BgmSubject.EXCLUDED.Name),
+				BgmSubject.NameCn.SET(BgmSubject.EXCLUDED.NameCn),
+			))
+
+		_, err := stmt.Exec(accessor.db)
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+		startIdx = endIdx
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+// BatchInsertArchivedSubject upserts subjects imported from an archive dump made at archivedAt
+// Their relations and staff are considered synced at archivedAt so that API sync jobs only fill the gaps
+func (accessor *KonomiCRAccessor) BatchInsertArchivedSubject(subjects []model.Subject, archivedAt time.Time, batchSize int) error {
+	bgmSubjects := model.ToBgmSubjects(subjects)
+	for i := range bgmSubjects {
+		bgmSubjects[i].RelationSyncedAt = &archivedAt
+		bgmSubjects[i].StaffSyncedAt = &archivedAt
+		bgmSubjects[i].ArchivedAt = &archivedAt
+	}
+
+	startIdx := 0
+	errs := make([]error, 0)
+	for startIdx < len(bgmSubjects) {
+		endIdx := startIdx + batchSize
+		if endIdx > len(bgmSubjects) {
+			endIdx = len(bgmSubjects)
+		}
+		stmt := BgmSubject.INSERT(BgmSubject.MutableColumns.Except(BgmSubject.FranchiseID), BgmSubject.ID).
+			MODELS(bgmSubjects[startIdx:endIdx]).
+			ON_CONFLICT(BgmSubject.ID).
+			DO_UPDATE(SET(
+				BgmSubject.Type.SET(BgmSubject.EXCLUDED.Type),
+				BgmSubject.Name.SET(BgmSubject.EXCLUDED.Name),
+				BgmSubject.NameCn.SET(BgmSubject.EXCLUDED.NameCn),
+				BgmSubject.Platform.SET(BgmSubject.EXCLUDED.Platform),
+				BgmSubject.AirDate.SET(BgmSubject.EXCLUDED.AirDate),
+				BgmSubject.Score.SET(BgmSubject.EXCLUDED.Score),
+				BgmSubject.Rank.SET(BgmSubject.EXCLUDED.Rank),
+				BgmSubject.CollectionTotal.SET(BgmSubject.EXCLUDED.CollectionTotal),
+				// keep the sync time of subjects refreshed from API after the dump was made
+				BgmSubject.RelationSyncedAt.SET(TimestampzExp(GREATEST(BgmSubject.RelationSyncedAt, BgmSubject.EXCLUDED.RelationSyncedAt))),
+				BgmSubject.StaffSyncedAt.SET(TimestampzExp(GREATEST(BgmSubject.StaffSyncedAt, BgmSubject.EXCLUDED.StaffSyncedAt))),
+				BgmSubject.ArchivedAt.SET(BgmSubject.EXCLUDED.ArchivedAt),
+			))
+
+		_, err := stmt.Exec(accessor.db)
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+		startIdx = endIdx
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+func (accessor *KonomiCRAccessor) MarkSubjectRelationSynced(sid string, syncedAt time.Time) error {
+	stmt := BgmSubject.INSERT(BgmSubject.ID, BgmSubject.RelationSyncedAt).
+		VALUES(sid, syncedAt).
+		ON_CONFLICT(BgmSubject.ID).
+		DO_UPDATE(SET(
+			BgmSubject.RelationSyncedAt.SET(TimestampzT(syncedAt)),
+		))
+
+	_, err := stmt.Exec(accessor.db)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (accessor *KonomiCRAccessor) BatchUpdateFranchiseId(franchiseIds map[string]string, batchSize int) error {
+	bgmSubjects := make([]jetmodel.BgmSubject, 0, len(franchiseIds))
+	for sid, franchiseId := range franchiseIds {
+		bgmSubjects = append(bgmSubjects, jetmodel.BgmSubject{
+			ID:          sid,
+			FranchiseID: &franchiseId,
+		})
+	}
+
+	startIdx := 0
+	errs := make([]error, 0)
+	for startIdx < len(bgmSubjects) {
+		endIdx := startIdx + batchSize
+		if endIdx > len(bgmSubjects) {
+			endIdx = len(bgmSubjects)
+		}
+		stmt := BgmSubject.INSERT(BgmSubject.ID, BgmSubject.FranchiseID).
+			MODELS(bgmSubjects[startIdx:endIdx]).
+			ON_CONFLICT(BgmSubject.ID).
+			DO_UPDATE(SET(
+				BgmSubject.FranchiseID.SET(BgmSubject.EXCLUDED.FranchiseID),
+			))
+
+		_, err := stmt.Exec(accessor.db)
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+		startIdx = endIdx
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+// GetScrapeWatermarks returns the newest collection time seen on each scraped subject page, keyed by the frontier key of the page
+func (accessor *KonomiCRAccessor) GetScrapeWatermarks() (map[string]time.Time, error) {
+	stmt := BgmScrapeWatermark.SELECT(BgmScrapeWatermark.AllColumns).
+		FROM(BgmScrapeWatermark).
+		WHERE(BgmScrapeWatermark.Watermark.IS_NOT_NULL())
+
+	var rows []jetmodel.BgmScrapeWatermark
+	err := stmt.Query(accessor.db, &rows)
+
+	if err != nil {
+		return nil, err
+	}
+
+	watermarks := make(map[string]time.Time, len(rows))
+	for _, row := range rows {
+		watermarks[row.PageKey] = *row.Watermark
+	}
+	return watermarks, nil
+}
+
+func (accessor *KonomiCRAccessor) GetSubjectPlatforms() (map[string]int64, error) {
+	stmt := BgmSubject.SELECT(BgmSubject.ID, BgmSubject.Platform).
+		FROM(BgmSubject).
+		WHERE(BgmSubject.Platform.IS_NOT_NULL())
+
+	var rows []jetmodel.BgmSubject
+	err := stmt.Query(accessor.db, &rows)
+
+	if err != nil {
+		return nil, err
+	}
+
+	platforms := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		platforms[row.ID] = *row.Platform
+	}
+	return platforms, nil
+}
+
+// ResetScrapeWatermarks forgets the watermarks of all subject pages, so that the next cold start scrapes them up to its interval
+func (accessor *KonomiCRAccessor) ResetScrapeWatermarks() (int64, error) {
+	stmt := BgmScrapeWatermark.DELETE().
+		WHERE(Bool(true))
+
+	res, err := stmt.Exec(accessor.db)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// BatchUpdateScrapeWatermark stores the watermarks keyed by the frontier key of their subject page
+func (accessor *KonomiCRAccessor) BatchUpdateScrapeWatermark(watermarks map[string]time.Time, batchSize int) error {
+	bgmWatermarks := make([]jetmodel.BgmScrapeWatermark, 0, len(watermarks))
+	for pageKey, watermark := range watermarks {
+		bgmWatermarks = append(bgmWatermarks, jetmodel.BgmScrapeWatermark{
+			PageKey:   pageKey,
+			Watermark: &watermark,
+		})
+	}
+
+	startIdx := 0
+	errs := make([]error, 0)
+	for startIdx < len(bgmWatermarks) {
+		endIdx := startIdx + batchSize
+		if endIdx > len(bgmWatermarks) {
+			endIdx = len(bgmWatermarks)
+		}
+		stmt := BgmScrapeWatermark.INSERT(BgmScrapeWatermark.AllColumns).
+			MODELS(bgmWatermarks[startIdx:endIdx]).
+			ON_CONFLICT(BgmScrapeWatermark.PageKey).
+			DO_UPDATE(SET(
+				BgmScrapeWatermark.Watermark.SET(BgmScrapeWatermark.EXCLUDED.Watermark),
+			))
+
+		_, err := stmt.Exec(accessor.db)
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+		startIdx = endIdx
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+func (accessor *KonomiCRAccessor) GetSubjectRelations() ([]model.SubjectRelation, error) {
+	stmt := BgmSubjectRelation.SELECT(BgmSubjectRelation.AllColumns).
+		FROM(BgmSubjectRelation)
+
+	var rows []jetmodel.BgmSubjectRelation
+	err := stmt.Query(accessor.db, &rows)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return model.FromBgmSubjectRelations(rows), nil
+}
+
+func (accessor *KonomiCRAccessor) BatchInsertSubjectRelation(relations []model.SubjectRelation, batchSize int) error {
+	startIdx := 0
+	errs := make([]error, 0)
+	for startIdx < len(relations) {
+		endIdx := startIdx + batchSize
+		if endIdx > len(relations) {
+			endIdx = len(relations)
+		}
+		stmt := BgmSubjectRelation.INSERT(BgmSubjectRelation.AllColumns).
+			MODELS(model.ToBgmSubjectRelations(relations[startIdx:endIdx])).
+			ON_CONFLICT(BgmSubjectRelation.SubjectID, BgmSubjectRelation.RelatedSubjectID).
+			DO_UPDATE(SET(
+				BgmSubjectRelation.RelationType.SET(BgmSubjectRelation.EXCLUDED.RelationType),
+				BgmSubjectRelation.Relation.SET(BgmSubjectRelation.EXCLUDED.Relation),
+			))
+
+		_, err := stmt.Exec(accessor.db)
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+		startIdx = endIdx
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+// GetStaffUnsyncedSubjectIds returns ids of collected subjects whose staff and characters were never synced or synced before syncedBefore
+func (accessor *KonomiCRAccessor) GetStaffUnsyncedSubjectIds(syncedBefore time.Time) ([]string, error) {
+	stmt := SELECT(DISTINCT(BgmUserCollection.SubjectID)).
+		FROM(BgmUserCollection.
+			LEFT_JOIN(BgmSubject, BgmSubject.ID.EQ(BgmUserCollection.SubjectID))).
+		WHERE(BgmSubject.StaffSyncedAt.IS_NULL().
+			OR(BgmSubject.StaffSyncedAt.LT(TimestampzT(syncedBefore))))
+
+	var rows []string
+	err := stmt.Query(accessor.db, &rows)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+func (accessor *KonomiCRAccessor) MarkSubjectStaffSynced(sid string, syncedAt time.Time) error {
+	stmt := BgmSubject.INSERT(BgmSubject.ID, BgmSubject.StaffSyncedAt).
+		VALUES(sid, syncedAt).
+		ON_CONFLICT(BgmSubject.ID).
+		DO_UPDATE(SET(
+			BgmSubject.StaffSyncedAt.SET(TimestampzT(syncedAt)),
+		))
+
+	_, err := stmt.Exec(accessor.db)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (accessor *KonomiCRAccessor) BatchInsertPerson(persons []model.Person, batchSize int) error {
+	startIdx := 0
+	errs := make([]error, 0)
+	for startIdx < len(persons) {
+		endIdx := startIdx + batchSize
+		if endIdx > len(persons) {
+			endIdx = len(persons)
+		}
+		stmt := BgmPerson.INSERT(BgmPerson.AllColumns).
+			MODELS(model.ToBgmPersons(persons[startIdx:endIdx])).
+			ON_CONFLICT(BgmPerson.ID).
+			DO_UPDATE(SET(
+				BgmPerson.Name.SET(BgmPerson.EXCLUDED.Name),
+				BgmPerson.Type.SET(BgmPerson.EXCLUDED.Type),
+				BgmPerson.Career.SET(BgmPerson.EXCLUDED.Career),
+			))
+
+		_, err := stmt.Exec(accessor.db)
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+		startIdx = endIdx
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+func (accessor *KonomiCRAccessor) BatchInsertSubjectPerson(subjectPersons []model.SubjectPerson, batchSize int) error {
+	startIdx := 0
+	errs := make([]error, 0)
+	for startIdx < len(subjectPersons) {
+		endIdx := startIdx + batchSize
+		if endIdx > len(subjectPersons) {
+			endIdx = len(subjectPersons)
+		}
+		stmt := BgmSubjectPerson.INSERT(BgmSubjectPerson.AllColumns).
+			MODELS(model.ToBgmSubjectPersons(subjectPersons[startIdx:endIdx])).
+			ON_CONFLICT(BgmSubjectPerson.SubjectID, BgmSubjectPerson.PersonID, BgmSubjectPerson.Role).
+			DO_UPDATE(SET(
+				// the api does not tell the position, so it must not clear the one imported from the archive
+				BgmSubjectPerson.Position.SET(IntExp(CASE().
+					WHEN(BgmSubjectPerson.EXCLUDED.Position.EQ(Int(0))).THEN(BgmSubjectPerson.Position).
+					ELSE(BgmSubjectPerson.EXCLUDED.Position))),
+			))
+
+		_, err := stmt.Exec(accessor.db)
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+		startIdx = endIdx
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+func (accessor *KonomiCRAccessor) BatchInsertCharacter(characters []model.Character, batchSize int) error {
+	startIdx := 0
+	errs := make([]error, 0)
+	for startIdx < len(characters) {
+		endIdx := startIdx + batchSize
+		if endIdx > len(characters) {
+			endIdx = len(characters)
+		}
+		stmt := BgmCharacter.INSERT(BgmCharacter.AllColumns).
+			MODELS(model.ToBgmCharacters(characters[startIdx:endIdx])).
+			ON_CONFLICT(BgmCharacter.ID).
+			DO_UPDATE(SET(
+				BgmCharacter.Name.SET(BgmCharacter.EXCLUDED.Name),
+				BgmCharacter.Type.SET(BgmCharacter.EXCLUDED.Type),
+			))
+
+		_, err := stmt.Exec(accessor.db)
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+		startIdx = endIdx
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+func (accessor *KonomiCRAccessor) BatchInsertSubjectCharacter(subjectCharacters []model.SubjectCharacter, batchSize int) error {
+	startIdx := 0
+	errs := make([]error, 0)
+	for startIdx < len(subjectCharacters) {
+		endIdx := startIdx + batchSize
+		if endIdx > len(subjectCharacters) {
+			endIdx = len(subjectCharacters)
+		}
+		stmt := BgmSubjectCharacter.INSERT(BgmSubjectCharacter.AllColumns).
+			MODELS(model.ToBgmSubjectCharacters(subjectCharacters[startIdx:endIdx])).
+			ON_CONFLICT(BgmSubjectCharacter.SubjectID, BgmSubjectCharacter.CharacterID).
+			DO_UPDATE(SET(
+				BgmSubjectCharacter.Role.SET(BgmSubjectCharacter.EXCLUDED.Role),
+			))
+
+		_, err := stmt.Exec(accessor.db)
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+		startIdx = endIdx
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+func (accessor *KonomiCRAccessor) BatchInsertScrapedCollection(collections []model.ScrapedCollection, batchSize int) error {
+	startIdx := 0
+	errs := make([]error, 0)
+	for startIdx < len(collections) {
+		endIdx := startIdx + batchSize
+		if endIdx > len(collections) {
+			endIdx = len(collections)
+		}
+		stmt := BgmScrapedCollection.INSERT(BgmScrapedCollection.AllColumns).
+			MODELS(model.ToBgmScrapedCollections(collections[startIdx:endIdx])).
+			ON_CONFLICT(BgmScrapedCollection.UserID, BgmScrapedCollection.SubjectID).
+			DO_UPDATE(SET(
+				BgmScrapedCollection.CollectionType.SET(BgmScrapedCollection.EXCLUDED.CollectionType),
+				BgmScrapedCollection.CollectedTime.SET(BgmScrapedCollection.EXCLUDED.CollectedTime),
+				BgmScrapedCollection.CollectedTimeZone.SET(BgmScrapedCollection.EXCLUDED.CollectedTimeZone),
+				BgmScrapedCollection.Rating.SET(BgmScrapedCollection.EXCLUDED.Rating),
+				BgmScrapedCollection.Comment.SET(BgmScrapedCollection.EXCLUDED.Comment),
+				BgmScrapedCollection.ScrapedAt.SET(BgmScrapedCollection.EXCLUDED.ScrapedAt),
+			))
+
+		_, err := stmt.Exec(accessor.db)
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+		startIdx = endIdx
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+// ShiftUnzonedScrapedCollections moves the times of scraped collections stored without a zone back by lateBy, and sets their zone
+// The zone marks them as shifted, so shifting again leaves them alone
+func (accessor *KonomiCRAccessor) ShiftUnzonedScrapedCollections(lateBy time.Duration, zone string) (int64, error) {
+	stmt := BgmScrapedCollection.UPDATE(BgmScrapedCollection.CollectedTime, BgmScrapedCollection.CollectedTimeZone).
+		SET(BgmScrapedCollection.CollectedTime.SUB(INTERVALd(lateBy)), String(zone)).
+		WHERE(BgmScrapedCollection.CollectedTimeZone.IS_NULL())
+
+	res, err := stmt.Exec(accessor.db)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (accessor *KonomiCRAccessor) GetScrapedCollectionsByUid(uid string) ([]model.ScrapedCollection, error) {
+	stmt := BgmScrapedCollection.SELECT(BgmScrapedCollection.AllColumns).
+		FROM(BgmScrapedCollection).
+		WHERE(BgmScrapedCollection.UserID.EQ(String(uid)))
+
+	var rows []jetmodel.BgmScrapedCollection
+	err := stmt.Query(accessor.db, &rows)
+
+	if err != nil {
+		return nil, err
+	}
+
+	collections := make([]model.ScrapedCollection, 0, len(rows))
+	for _, row := range rows {
+		collections = append(collections, model.FromBgmScrapedCollection(row))
+	}
+	return collections, nil
+}
+
+func (accessor *KonomiCRAccessor) BatchInsertUserFriend(friends []model.UserFriend, batchSize int) error {
+	startIdx := 0
+	errs := make([]error, 0)
+	for startIdx < len(friends) {
+		endIdx := startIdx + batchSize
+		if endIdx > len(friends) {
+			endIdx = len(friends)
+		}
+		stmt := BgmUserFriend.INSERT(BgmUserFriend.AllColumns).
+			MODELS(model.ToBgmUserFriends(friends[startIdx:endIdx])).
+			ON_CONFLICT(BgmUserFriend.UserID, BgmUserFriend.FriendID).
+			DO_UPDATE(SET(
+				BgmUserFriend.DiscoveredAt.SET(BgmUserFriend.EXCLUDED.DiscoveredAt),
+			))
+
+		_, err := stmt.Exec(accessor.db)
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+		startIdx = endIdx
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+func (accessor *KonomiCRAccessor) BatchInsertCandidateSighting(sightings []model.CandidateSighting, batchSize int) error {
+	startIdx := 0
+	errs := make([]error, 0)
+	for startIdx < len(sightings) {
+		endIdx := startIdx + batchSize
+		if endIdx > len(sightings) {
+			endIdx = len(sightings)
+		}
+		stmt := BgmCandidateSighting.INSERT(BgmCandidateSighting.AllColumns).
+			MODELS(model.ToBgmCandidateSightings(sightings[startIdx:endIdx])).
+			ON_CONFLICT(BgmCandidateSighting.UserID, BgmCandidateSighting.Source).
+			DO_UPDATE(SET(
+				BgmCandidateSighting.SightedAt.SET(BgmCandidateSighting.EXCLUDED.SightedAt),
+			))
+
+		_, err := stmt.Exec(accessor.db)
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+		startIdx = endIdx
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+func (accessor *KonomiCRAccessor) GetCandidateCount() (int, error) {
+	candidates := BgmCandidateSighting.SELECT(BgmCandidateSighting.UserID).
+		FROM(BgmCandidateSighting).
+		GROUP_BY(BgmCandidateSighting.UserID).
+		AsTable("candidates")
+	stmt := SELECT(COUNT(STAR)).
+		FROM(candidates)
+
+	var rows []struct {
+		Count int
+	}
+	err := stmt.Query(accessor.db, &rows)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return rows[0].Count, nil
+}
+
+// GetCandidatesPaginated aggregates the sightings of each candidate, ranked by the number of subjects they were seen on
+// and then by their latest sighting, with the user id breaking ties so that pages are stable
+func (accessor *KonomiCRAccessor) GetCandidatesPaginated(offset, limit int) ([]model.Candidate, error) {
+	subjectCnt := COUNT(DISTINCT(BgmCandidateSighting.SubjectID))
+	lastSightedAt := MAX(BgmCandidateSighting.SightedAt)
+	stmt := SELECT(
+		BgmCandidateSighting.UserID.AS("candidate.user_id"),
+		subjectCnt.AS("candidate.subject_cnt"),
+		lastSightedAt.AS("candidate.last_sighted_at"),
+	).
+		FROM(BgmCandidateSighting).
+		GROUP_BY(BgmCandidateSighting.UserID).
+		ORDER_BY(subjectCnt.DESC(), lastSightedAt.DESC(), BgmCandidateSighting.UserID.ASC()).
+		LIMIT(int64(limit)).
+		OFFSET(int64(offset))
+
+	var rows []struct {
+		UserID        string    `alias:"candidate.user_id"`
+		SubjectCnt    int64     `alias:"candidate.subject_cnt"`
+		LastSightedAt time.Time `alias:"candidate.last_sighted_at"`
+	}
+	err := stmt.Query(accessor.db, &rows)
+
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]model.Candidate, 0, len(rows))
+	for _, row := range rows {
+		candidates = append(candidates, model.Candidate{
+			UserID:        row.UserID,
+			SubjectCnt:    row.SubjectCnt,
+			LastSightedAt: row.LastSightedAt,
+		})
+	}
+	return candidates, nil
+}
+
+func (accessor *KonomiCRAccessor) DeleteCandidateSightings() error {
+	stmt := BgmCandidateSighting.DELETE().
+		WHERE(Bool(true))
+
+	_, err := stmt.Exec(accessor.db)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetCandidateEvaluations returns the last evaluation of the given users, users never evaluated are absent
+func (accessor *KonomiCRAccessor) GetCandidateEvaluations(uids []string) (map[string]model.CandidateEvaluation, error) {
+	evaluations := make(map[string]model.CandidateEvaluation, len(uids))
+	if len(uids) == 0 {
+		return evaluations, nil
+	}
+	uidExps := make([]Expression, 0, len(uids))
+	for _, uid := range uids {
+		uidExps = append(uidExps, String(uid))
+	}
+	stmt := BgmCandidate.SELECT(BgmCandidate.AllColumns).
+		FROM(BgmCandidate).
+		WHERE(BgmCandidate.UserID.IN(uidExps...))
+
+	var rows []jetmodel.BgmCandidate
+	err := stmt.Query(accessor.db, &rows)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		evaluation, err := model.FromBgmCandidate(row)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read evaluation of candidate %s (%w)", row.UserID, err)
+		}
+		evaluations[row.UserID] = evaluation
+	}
+	return evaluations, nil
+}
+
+func (accessor *KonomiCRAccessor) GetEvaluatedCandidateIds() ([]string, error) {
+	stmt := BgmCandidate.SELECT(BgmCandidate.UserID).
+		FROM(BgmCandidate)
+
+	var rows []string
+	err := stmt.Query(accessor.db, &rows)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+func (accessor *KonomiCRAccessor) InsertCandidateEvaluation(evaluation model.CandidateEvaluation) error {
+	bgmCandidate, err := evaluation.ToBgmCandidate()
+	if err != nil {
+		return err
+	}
+	stmt := BgmCandidate.INSERT(BgmCandidate.AllColumns).
+		MODEL(bgmCandidate).
+		ON_CONFLICT(BgmCandidate.UserID).
+		DO_UPDATE(SET(
+			BgmCandidate.SubjectType.SET(BgmCandidate.EXCLUDED.SubjectType),
+			BgmCandidate.Passed.SET(BgmCandidate.EXCLUDED.Passed),
+			BgmCandidate.FailedRule.SET(BgmCandidate.EXCLUDED.FailedRule),
+			BgmCandidate.Measured.SET(BgmCandidate.EXCLUDED.Measured),
+			BgmCandidate.EvaluatedAt.SET(BgmCandidate.EXCLUDED.EvaluatedAt),
+		))
+
+	_, err = stmt.Exec(accessor.db)
+
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetLatestTierStats returns the tier distribution recorded by the last stats run, or nothing if stats never ran
+func (accessor *KonomiCRAccessor) GetLatestTierStats() ([]model.TierStat, error) {
+	latestRunStmt := BgmTierStats.SELECT(BgmTierStats.RunAt).
+		FROM(BgmTierStats).
+		ORDER_BY(BgmTierStats.RunAt.DESC()).
+		LIMIT(1)
+
+	var latestRuns []jetmodel.BgmTierStats
+	if err := latestRunStmt.Query(accessor.db, &latestRuns); err != nil {
+		return nil, err
+	}
+	if len(latestRuns) == 0 {
+		return nil, nil
+	}
+
+	stmt := BgmTierStats.SELECT(BgmTierStats.AllColumns).
+		FROM(BgmTierStats).
+		WHERE(BgmTierStats.RunAt.EQ(TimestampzT(latestRuns[0].RunAt)))
+
+	var rows []jetmodel.BgmTierStats
+	err := stmt.Query(accessor.db, &rows)
+
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]model.TierStat, 0, len(rows))
+	for _, row := range rows {
+		stats = append(stats, model.TierStat{
+			RunAt:   row.RunAt,
+			Tier:    int(row.Tier),
+			UserCnt: int(*row.UserCnt),
+		})
+	}
+	return stats, nil
+}
+
+func (accessor *KonomiCRAccessor) BatchInsertTierStats(stats []model.TierStat, batchSize int) error {
+	startIdx := 0
+	errs := make([]error, 0)
+	for startIdx < len(stats) {
+		endIdx := startIdx + batchSize
+		if endIdx > len(stats) {
+			endIdx = len(stats)
+		}
+		stmt := BgmTierStats.INSERT(BgmTierStats.AllColumns).
+			MODELS(model.ToBgmTierStats(stats[startIdx:endIdx])).
+			ON_CONFLICT(BgmTierStats.RunAt, BgmTierStats.Tier).
+			DO_UPDATE(SET(
+				BgmTierStats.UserCnt.SET(BgmTierStats.EXCLUDED.UserCnt),
+			))
+
+		_, err := stmt.Exec(accessor.db)
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+		startIdx = endIdx
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package helper
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/AlcEccentric/beck-mizuki/model"
+	"github.com/AlcEccentric/beck-mizuki/util"
+)
+
+const (
+	BucketActivityModelName  = "bucket"
+	DecayActivityModelName   = "decay"
+	PoissonActivityModelName = "poisson"
+)
+
+// ActivityModel tells if a user is active from the watched collections of the activity check window
+type ActivityModel interface {
+	Name() string
+	// Measure is given the recent watched collections sorted from the newest to the oldest
+	Measure(now time.Time, rawWatchedCount int, recentWatched []model.Collection) ActivityMeasurement
+}
+
+// ActivityMeasurement is what an activity model measured, Measured is the value the model compares against its threshold
+type ActivityMeasurement struct {
+	Active   bool
+	Measured float64
+	Reason   string
+}
+
+func NewActivityModel(thresholds VipThresholds) (ActivityModel, error) {
+	switch thresholds.ActivityModel {
+	case BucketActivityModelName:
+		return BucketActivityModel{thresholds: thresholds}, nil
+	case DecayActivityModelName:
+		return DecayActivityModel{thresholds: thresholds}, nil
+	case PoissonActivityModelName:
+		return PoissonActivityModel{thresholds: thresholds}, nil
+	default:
+		return nil, fmt.Errorf("activity model %s is not supported", thresholds.ActivityModel)
+	}
+}
+
+// BucketActivityModel splits the activity check window into intervals of the tier of the user, counted in calendar days from today
+// The user is active if up to NonWatchedIntervalTolerance intervals have no watched collection
+// Measured is the number of intervals without a watched collection
+type BucketActivityModel struct {
+	thresholds VipThresholds
+}
+
+func (m BucketActivityModel) Name() string {
+	return BucketActivityModelName
+}
+
+func (m BucketActivityModel) Measure(now time.Time, rawWatchedCount int, recentWatched []model.Collection) ActivityMeasurement {
+	intervalDays := m.thresholds.IntervalDays(m.thresholds.Tier(rawWatchedCount))
+	missedIntervals := missedWatchedIntervals(now, recentWatched, intervalDays, m.thresholds.CalendarLocation)
+	return ActivityMeasurement{
+		Active:   missedIntervals <= m.thresholds.NonWatchedIntervalTolerance,
+		Measured: float64(missedIntervals),
+		Reason:   fmt.Sprintf("missed %d intervals of %d days (tolerance %d)", missedIntervals, intervalDays, m.thresholds.NonWatchedIntervalTolerance),
+	}
+}
+
+// DecayActivityModel weighs each watched collection by half every ActivityHalfLifeDays calendar days
+// The user is active if the sum of the weights is at least MinDecayedActivityScore
+// Unlike buckets, a recent watched collection makes up for older gaps
+type DecayActivityModel struct {
+	thresholds VipThresholds
+}
+
+func (m DecayActivityModel) Name() string {
+	return DecayActivityModelName
+}
+
+func (m DecayActivityModel) Measure(now time.Time, rawWatchedCount int, recentWatched []model.Collection) ActivityMeasurement {
+	score := 0.0
+	for _, collection := range recentWatched {
+		ageInDays := util.CalendarDaysBetween(collection.CollectedTime, now, m.thresholds.CalendarLocation)
+		score += math.Exp2(-float64(ageInDays) / m.thresholds.ActivityHalfLifeDays)
+	}
+	return ActivityMeasurement{
+		Active:   score >= m.thresholds.MinDecayedActivityScore,
+		Measured: score,
+		Reason: fmt.Sprintf("decayed activity score %.2f with a half-life of %.0f days (min %.2f)",
+			score, m.thresholds.ActivityHalfLifeDays, m.thresholds.MinDecayedActivityScore),
+	}
+}
+
+// PoissonActivityModel estimates the daily watching rate over the activity check window, assuming watched collections arrive as a Poisson process
+// The user is active if they would watch something in the next interval of their tier with at least MinPoissonWatchProbability,
+// and if the rate makes the silence since their latest watched collection at least MinPoissonSilenceProbability likely
+// Measured is the probability to watch something in the next interval, 0 if the silence is too unlikely
+type PoissonActivityModel struct {
+	thresholds VipThresholds
+}
+
+func (m PoissonActivityModel) Name() string {
+	return PoissonActivityModelName
+}
+
+func (m PoissonActivityModel) Measure(now time.Time, rawWatchedCount int, recentWatched []model.Collection) ActivityMeasurement {
+	if len(recentWatched) == 0 {
+		return ActivityMeasurement{Reason: "has no watched collection in the activity check window"}
+	}
+
+	intervalDays := m.thresholds.IntervalDays(m.thresholds.Tier(rawWatchedCount))
+	rate := float64(len(recentWatched)) / float64(m.thresholds.ActivityCheckDays)
+	watchProbability := 1 - math.Exp(-rate*float64(intervalDays))
+	silenceDays := util.CalendarDaysBetween(recentWatched[0].CollectedTime, now, m.thresholds.CalendarLocation)
+	silenceProbability := math.Exp(-rate * float64(silenceDays))
+	if silenceProbability < m.thresholds.MinPoissonSilenceProbability {
+		return ActivityMeasurement{
+			Reason: fmt.Sprintf("silent for %d days which is %.3f likely at %.2f watched per day (min %.3f)",
+				silenceDays, silenceProbability, rate, m.thresholds.MinPoissonSilenceProbability),
+		}
+	}
+	return ActivityMeasurement{
+		Active:   watchProbability >= m.thresholds.MinPoissonWatchProbability,
+		Measured: watchProbability,
+		Reason: fmt.Sprintf("watches in the next %d days with probability %.3f at %.2f watched per day (min %.3f)",
+			intervalDays, watchProbability, rate, m.thresholds.MinPoissonWatchProbability),
+	}
+}
+
+// missedWatchedIntervals counts the intervals without a collection, from today to the day of the oldest given collection
+// collections must be sorted from the newest to the oldest
+func missedWatchedIntervals(now time.Time, collections []model.Collection, intervalDays int, calendarLocation *time.Location) int {
+	lastIntervalIdx := -1
+	missedIntervals := 0
+	for _, collection := range collections {
+		curIntervalIdx := util.CalendarDaysBetween(collection.CollectedTime, now, calendarLocation) / intervalDays
+		// further collections in the same interval do not cover another one
+		if curIntervalIdx > lastIntervalIdx {
+			missedIntervals += (curIntervalIdx - lastIntervalIdx) - 1
+			lastIntervalIdx = curIntervalIdx
+		}
+	}
+	return missedIntervals
+}
@@ -0,0 +1,172 @@
+package helper
+
+import (
+	"sort"
+	"time"
+
+	"github.com/AlcEccentric/beck-mizuki/model"
+)
+
+const (
+	BurstImportRuleName   = "burst_import"
+	UniformRatingRuleName = "uniform_rating"
+	WatchVelocityRuleName = "watch_velocity"
+)
+
+type BotDetectionAction string
+
+const (
+	// FlagBots keeps the users detected as bots but records the reason
+	FlagBots BotDetectionAction = "flag"
+	// ExcludeBots rejects the users detected as bots
+	ExcludeBots BotDetectionAction = "exclude"
+)
+
+// botDetector is implemented by the rules that tell if the collections of a user are meaningful rather than if the user is active
+// Their failures are turned into flags when bots are flagged instead of excluded
+type botDetector interface {
+	Rule
+	detectsBots()
+}
+
+// ApplyBotDetectionAction makes the bot detectors of the rule sets flag users instead of rejecting them if action is FlagBots
+func ApplyBotDetectionAction(ruleSets map[model.SubjectType][]Rule, action BotDetectionAction) {
+	if action != FlagBots {
+		return
+	}
+	for _, rules := range ruleSets {
+		for i, rule := range rules {
+			if detector, ok := rule.(botDetector); ok {
+				rules[i] = flaggingRule{detector}
+			}
+		}
+	}
+}
+
+// BotDetectors returns the bot detectors of the rules
+func BotDetectors(rules []Rule) []Rule {
+	detectors := make([]Rule, 0)
+	for _, rule := range rules {
+		if _, ok := rule.(botDetector); ok {
+			detectors = append(detectors, rule)
+		}
+	}
+	return detectors
+}
+
+type flaggingRule struct {
+	botDetector
+}
+
+func (rule flaggingRule) Evaluate(ctx *EvaluationContext) RuleResult {
+	result := rule.botDetector.Evaluate(ctx)
+	if result.Err == nil && !result.Passed {
+		result.Passed = true
+		result.Flagged = true
+	}
+	return result
+}
+
+// BurstImportRule rejects users with more than BurstMaxCollections watched collections made within BurstWindowMinutes,
+// as collections imported from another site in bulk carry meaningless timestamps
+type BurstImportRule struct {
+	thresholds VipThresholds
+}
+
+func (rule BurstImportRule) Name() string {
+	return BurstImportRuleName
+}
+
+func (rule BurstImportRule) detectsBots() {}
+
+func (rule BurstImportRule) Evaluate(ctx *EvaluationContext) RuleResult {
+	ratedWatched, err := ctx.Source.RatedCollections(model.Watched)
+	if err != nil {
+		return errored(rule, err, "failed to get rated watched collections")
+	}
+	burstWindow := time.Duration(rule.thresholds.BurstWindowMinutes) * time.Minute
+	burstSize := maxCollectionsInWindow(ratedWatched, burstWindow)
+	if burstSize > rule.thresholds.BurstMaxCollections {
+		return failed(rule, float64(burstSize), "%d watched collections made within %s, over %d", burstSize, burstWindow, rule.thresholds.BurstMaxCollections)
+	}
+	return passed(rule, float64(burstSize), "at most %d watched collections made within %s", burstSize, burstWindow)
+}
+
+// UniformRatingRule rejects users giving the same rating to more than UniformRatingMaxShare of their rated collections,
+// as their ratings tell nothing about their taste
+type UniformRatingRule struct {
+	thresholds VipThresholds
+}
+
+func (rule UniformRatingRule) Name() string {
+	return UniformRatingRuleName
+}
+
+func (rule UniformRatingRule) detectsBots() {}
+
+func (rule UniformRatingRule) Evaluate(ctx *EvaluationContext) RuleResult {
+	ratedWatched, err := ctx.Source.RatedCollections(model.Watched)
+	if err != nil {
+		return errored(rule, err, "failed to get rated watched collections")
+	}
+	if len(ratedWatched) < rule.thresholds.UniformRatingMinRated {
+		return passed(rule, 0, "%d rated watched collections are too few to tell", len(ratedWatched))
+	}
+
+	ratingCounts := make(map[int64]int)
+	mostCommonRating := int64(0)
+	for _, collection := range ratedWatched {
+		ratingCounts[collection.Rating]++
+		if ratingCounts[collection.Rating] > ratingCounts[mostCommonRating] {
+			mostCommonRating = collection.Rating
+		}
+	}
+	share := float64(ratingCounts[mostCommonRating]) / float64(len(ratedWatched))
+	if share > rule.thresholds.UniformRatingMaxShare {
+		return failed(rule, share, "%.0f%% of rated watched collections are rated %d, over %.0f%%", share*100, mostCommonRating, rule.thresholds.UniformRatingMaxShare*100)
+	}
+	return passed(rule, share, "%.0f%% of rated watched collections are rated %d", share*100, mostCommonRating)
+}
+
+// WatchVelocityRule rejects users averaging more than MaxWatchedPerDay watched collections over any VelocityWindowDays
+type WatchVelocityRule struct {
+	thresholds VipThresholds
+}
+
+func (rule WatchVelocityRule) Name() string {
+	return WatchVelocityRuleName
+}
+
+func (rule WatchVelocityRule) detectsBots() {}
+
+func (rule WatchVelocityRule) Evaluate(ctx *EvaluationContext) RuleResult {
+	ratedWatched, err := ctx.Source.RatedCollections(model.Watched)
+	if err != nil {
+		return errored(rule, err, "failed to get rated watched collections")
+	}
+	velocityWindow := time.Duration(rule.thresholds.VelocityWindowDays) * 24 * time.Hour
+	velocity := float64(maxCollectionsInWindow(ratedWatched, velocityWindow)) / float64(rule.thresholds.VelocityWindowDays)
+	if velocity > float64(rule.thresholds.MaxWatchedPerDay) {
+		return failed(rule, velocity, "watched %.1f per day over %d days, over %d", velocity, rule.thresholds.VelocityWindowDays, rule.thresholds.MaxWatchedPerDay)
+	}
+	return passed(rule, velocity, "watched at most %.1f per day over %d days", velocity, rule.thresholds.VelocityWindowDays)
+}
+
+// maxCollectionsInWindow returns the largest number of collections made within a time window
+func maxCollectionsInWindow(collections []model.Collection, window time.Duration) int {
+	times := make([]time.Time, 0, len(collections))
+	for _, collection := range collections {
+		times = append(times, collection.CollectedTime)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	maxCnt := 0
+	startIdx := 0
+	for endIdx := range times {
+		for times[endIdx].Sub(times[startIdx]) > window {
+			startIdx++
+		}
+		maxCnt = max(maxCnt, endIdx-startIdx+1)
+	}
+	return maxCnt
+}
@@ -0,0 +1,31 @@
+package helper
+
+import (
+	"time"
+
+	"github.com/AlcEccentric/beck-mizuki/dao"
+	"github.com/AlcEccentric/beck-mizuki/model"
+	"github.com/rs/zerolog/log"
+)
+
+// GetAnimeCollections fetches the filtered anime collections of every given collection type for the user
+// If since is not zero, only collections made since then are fetched
+func GetAnimeCollections(bgmAPI *dao.BgmApiAccessor, subjectFilter *SubjectFilter, uid string, ctypes []model.CollectionType, since time.Time) ([]model.Collection, error) {
+	collections := make([]model.Collection, 0)
+	for _, ctype := range ctypes {
+		var newCollections []model.Collection
+		var err error
+		if !since.IsZero() {
+			newCollections, err = bgmAPI.GetRecentCollections(uid, ctype, model.Anime, subjectFilter.CollectionFilter(ctype), since)
+		} else {
+			newCollections, err = bgmAPI.GetCollections(uid, ctype, model.Anime, subjectFilter.CollectionFilter(ctype))
+		}
+
+		if err != nil {
+			return nil, err
+		}
+		log.Debug().Msgf("Found %d filtered %s collections for user: %s", len(newCollections), ctype.String(), uid)
+		collections = append(collections, newCollections...)
+	}
+	return collections, nil
+}
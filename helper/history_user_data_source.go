@@ -0,0 +1,75 @@
+package helper
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/AlcEccentric/beck-mizuki/model"
+	"github.com/AlcEccentric/beck-mizuki/util"
+)
+
+// historyUserDataSource replays stored collections as if the user was evaluated at asOf
+// Stored collections were filtered before being persisted, so counts are lower than the ones the API would return
+type historyUserDataSource struct {
+	asOf             time.Time
+	calendarLocation *time.Location
+	collections      map[model.CollectionType][]model.Collection // newest first
+}
+
+// NewHistoryUserDataSource only keeps the collections of the subject type made before asOf
+// Recent windows are counted in calendar days of calendarLocation
+func NewHistoryUserDataSource(collections []model.Collection, subjectType model.SubjectType, asOf time.Time, calendarLocation *time.Location) UserDataSource {
+	source := &historyUserDataSource{
+		asOf:             asOf,
+		calendarLocation: calendarLocation,
+		collections:      make(map[model.CollectionType][]model.Collection),
+	}
+	for _, collection := range collections {
+		if collection.SubjectType != int64(subjectType) || collection.CollectedTime.After(asOf) {
+			continue
+		}
+		ctype := model.CollectionType(collection.CollectionType)
+		source.collections[ctype] = append(source.collections[ctype], collection)
+	}
+	for _, typedCollections := range source.collections {
+		sort.Slice(typedCollections, func(i, j int) bool {
+			return typedCollections[i].CollectedTime.After(typedCollections[j].CollectedTime)
+		})
+	}
+	return source
+}
+
+func (source *historyUserDataSource) Now() time.Time {
+	return source.asOf
+}
+
+func (source *historyUserDataSource) CollectionCount(ctype model.CollectionType) (int, error) {
+	return len(source.collections[ctype]), nil
+}
+
+func (source *historyUserDataSource) CollectionTime(ctype model.CollectionType, offset int) (time.Time, error) {
+	typedCollections := source.collections[ctype]
+	if offset < 0 || offset >= len(typedCollections) {
+		return time.Time{}, fmt.Errorf("offset %d is out of the %d %s collections", offset, len(typedCollections), ctype.String())
+	}
+	return typedCollections[offset].CollectedTime, nil
+}
+
+func (source *historyUserDataSource) RatedCollections(ctype model.CollectionType) ([]model.Collection, error) {
+	return source.RecentRatedCollections(ctype, 0)
+}
+
+func (source *historyUserDataSource) RecentRatedCollections(ctype model.CollectionType, recentWindowInDays int) ([]model.Collection, error) {
+	oldestAcceptedTime := util.CalendarWindowStart(source.asOf, recentWindowInDays, source.calendarLocation)
+	ratedCollections := make([]model.Collection, 0)
+	for _, collection := range source.collections[ctype] {
+		if recentWindowInDays > 0 && collection.CollectedTime.Before(oldestAcceptedTime) {
+			break
+		}
+		if collection.Rating > 0 {
+			ratedCollections = append(ratedCollections, collection)
+		}
+	}
+	return ratedCollections, nil
+}
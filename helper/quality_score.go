@@ -0,0 +1,205 @@
+package helper
+
+import (
+	"math"
+
+	"github.com/AlcEccentric/beck-mizuki/model"
+	"github.com/AlcEccentric/beck-mizuki/util"
+)
+
+// ratings are from 1 to 10, so a rating set has a variance up to 4.5^2 and two sets have means up to 9 apart
+const maxRatingSpread = 4.5*4.5 + 9*9
+
+const (
+	WatchedCountComponent   = "watched_count"
+	HistoryLengthComponent  = "history_length"
+	RegularityComponent     = "regularity"
+	RatingCoverageComponent = "rating_coverage"
+	RatingVarianceComponent = "rating_variance"
+)
+
+// QualityModel weighs how much, how long, how regularly and how informatively a user rates into a score in [0, 1]
+// Each component is normalized to [0, 1] and the weights sum up to 1
+type QualityModel struct {
+	Weights          map[string]float64
+	FullWatchedCnt   int           // watched count scoring 1
+	FullHistoryDays  int           // age of the earliest watched collection scoring 1
+	FullRatingStdDev float64       // rating standard deviation scoring 1, users rating everything alike tell little about their taste
+	Thresholds       VipThresholds // activity check window and intervals the regularity is measured on
+}
+
+func DefaultQualityModel(thresholds VipThresholds) QualityModel {
+	return QualityModel{
+		Weights: map[string]float64{
+			WatchedCountComponent:   util.QualityWatchedCountWeight,
+			HistoryLengthComponent:  util.QualityHistoryLengthWeight,
+			RegularityComponent:     util.QualityRegularityWeight,
+			RatingCoverageComponent: util.QualityRatingCoverageWeight,
+			RatingVarianceComponent: util.QualityRatingVarianceWeight,
+		},
+		FullWatchedCnt:   util.QualityFullWatchedCnt,
+		FullHistoryDays:  util.QualityFullHistoryDays,
+		FullRatingStdDev: util.QualityFullRatingStdDev,
+		Thresholds:       thresholds,
+	}
+}
+
+type qualityComponent struct {
+	name    string
+	measure func(qm QualityModel, source UserDataSource) (float64, error)
+}
+
+// components are measured from the cheapest to the most expensive, so that hopeless users are given up early
+var qualityComponents = []qualityComponent{
+	{WatchedCountComponent, measureWatchedCount},
+	{HistoryLengthComponent, measureHistoryLength},
+	{RegularityComponent, measureRegularity},
+	{RatingCoverageComponent, measureRatingCoverage},
+	{RatingVarianceComponent, measureRatingVariance},
+}
+
+// Score returns the quality score of the user, or an upper bound of it below minScore if the user cannot reach minScore
+// The unclamped value of each measured component is returned too, components skipped by an early exit are missing
+func (qm QualityModel) Score(source UserDataSource, minScore float64) (float64, map[string]float64, error) {
+	score := 0.0
+	remainingWeight := 0.0
+	for _, component := range qualityComponents {
+		remainingWeight += qm.Weights[component.name]
+	}
+
+	components := make(map[string]float64, len(qualityComponents))
+	for _, component := range qualityComponents {
+		weight := qm.Weights[component.name]
+		remainingWeight -= weight
+		if weight == 0 {
+			continue
+		}
+		value, err := component.measure(qm, source)
+		if err != nil {
+			return 0, nil, err
+		}
+		components[component.name] = value
+		score += weight * clamp(value)
+		if score+remainingWeight < minScore {
+			return score + remainingWeight, components, nil
+		}
+	}
+	return score, components, nil
+}
+
+// MaxGain returns an upper bound of the score a user can gain in elapsedDays, given the components measured by Score
+// Components missing were counted at their full weight in the upper bound returned by Score, so they cannot gain more
+// New collections are assumed to be watched at SustainedWatchedPerDay and all rated, which moves every component the most
+func (qm QualityModel) MaxGain(components map[string]float64, elapsedDays float64) float64 {
+	newWatchedCnt := elapsedDays * qm.Thresholds.SustainedWatchedPerDay
+	rawWatchedCount := components[WatchedCountComponent] * float64(qm.FullWatchedCnt)
+	ratedWatchedCount := components[RatingCoverageComponent] * rawWatchedCount
+	longestIntervalDays := max(qm.Thresholds.T1IntervalDays, qm.Thresholds.T2IntervalDays, qm.Thresholds.T3IntervalDays)
+
+	gain := 0.0
+	for name, value := range components {
+		var newValue float64
+		switch name {
+		case WatchedCountComponent:
+			newValue = value + newWatchedCnt/float64(qm.FullWatchedCnt)
+		case HistoryLengthComponent:
+			newValue = value + elapsedDays/float64(qm.FullHistoryDays)
+		case RegularityComponent:
+			// each interval entering the activity check window can be covered
+			newValue = value + (elapsedDays+float64(longestIntervalDays))/float64(qm.Thresholds.ActivityCheckDays)
+		case RatingCoverageComponent:
+			newValue = 1
+			if rawWatchedCount > 0 {
+				newValue = (ratedWatchedCount + newWatchedCnt) / (rawWatchedCount + newWatchedCnt)
+			}
+		case RatingVarianceComponent:
+			newValue = 1
+			if ratedWatchedCount > 0 {
+				// mixing in a share of new ratings adds at most their own variance and the squared gap of the means
+				newShare := newWatchedCnt / (ratedWatchedCount + newWatchedCnt)
+				stdDev := value * qm.FullRatingStdDev
+				newValue = math.Sqrt(stdDev*stdDev+newShare*maxRatingSpread) / qm.FullRatingStdDev
+			}
+		default:
+			newValue = 1
+		}
+		gain += qm.Weights[name] * math.Max(0, clamp(newValue)-clamp(value))
+	}
+	return gain
+}
+
+func measureWatchedCount(qm QualityModel, source UserDataSource) (float64, error) {
+	rawWatchedCount, err := source.CollectionCount(model.Watched)
+	if err != nil {
+		return 0, err
+	}
+	return float64(rawWatchedCount) / float64(qm.FullWatchedCnt), nil
+}
+
+func measureHistoryLength(qm QualityModel, source UserDataSource) (float64, error) {
+	rawWatchedCount, err := source.CollectionCount(model.Watched)
+	if err != nil || rawWatchedCount == 0 {
+		return 0, err
+	}
+	earliestWatchedTime, err := source.CollectionTime(model.Watched, rawWatchedCount-1)
+	if err != nil {
+		return 0, err
+	}
+	return elapsedDays(source.Now().Sub(earliestWatchedTime)) / float64(qm.FullHistoryDays), nil
+}
+
+// measureRegularity returns the share of the intervals of the activity check window with a watched collection
+func measureRegularity(qm QualityModel, source UserDataSource) (float64, error) {
+	rawWatchedCount, err := source.CollectionCount(model.Watched)
+	if err != nil {
+		return 0, err
+	}
+	recentWatched, err := source.RecentRatedCollections(model.Watched, qm.Thresholds.ActivityCheckDays)
+	if err != nil {
+		return 0, err
+	}
+
+	intervalDays := qm.Thresholds.IntervalDays(qm.Thresholds.Tier(rawWatchedCount))
+	intervalCnt := int(math.Ceil(float64(qm.Thresholds.ActivityCheckDays) / float64(intervalDays)))
+	coveredIntervals := make(map[int]struct{})
+	for _, collection := range recentWatched {
+		intervalIdx := util.CalendarDaysBetween(collection.CollectedTime, source.Now(), qm.Thresholds.CalendarLocation) / intervalDays
+		if intervalIdx < intervalCnt {
+			coveredIntervals[intervalIdx] = struct{}{}
+		}
+	}
+	return float64(len(coveredIntervals)) / float64(intervalCnt), nil
+}
+
+func measureRatingCoverage(qm QualityModel, source UserDataSource) (float64, error) {
+	rawWatchedCount, err := source.CollectionCount(model.Watched)
+	if err != nil || rawWatchedCount == 0 {
+		return 0, err
+	}
+	ratedWatched, err := source.RatedCollections(model.Watched)
+	if err != nil {
+		return 0, err
+	}
+	return float64(len(ratedWatched)) / float64(rawWatchedCount), nil
+}
+
+func measureRatingVariance(qm QualityModel, source UserDataSource) (float64, error) {
+	ratedWatched, err := source.RatedCollections(model.Watched)
+	if err != nil || len(ratedWatched) == 0 {
+		return 0, err
+	}
+	sum := 0.0
+	for _, collection := range ratedWatched {
+		sum += float64(collection.Rating)
+	}
+	mean := sum / float64(len(ratedWatched))
+	squaredDiffSum := 0.0
+	for _, collection := range ratedWatched {
+		squaredDiffSum += (float64(collection.Rating) - mean) * (float64(collection.Rating) - mean)
+	}
+	return math.Sqrt(squaredDiffSum/float64(len(ratedWatched))) / qm.FullRatingStdDev, nil
+}
+
+func clamp(value float64) float64 {
+	return math.Max(0, math.Min(1, value))
+}
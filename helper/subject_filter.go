@@ -0,0 +1,261 @@
+package helper
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/AlcEccentric/beck-mizuki/model"
+	"github.com/AlcEccentric/beck-mizuki/util"
+	"github.com/rs/zerolog/log"
+	"github.com/tidwall/gjson"
+)
+
+//go:embed subject_filter_rules.json
+var defaultSubjectFilterRules []byte
+
+// names of the checks collections can be dropped by
+const (
+	ratingCheck          = "rating"
+	deniedSubjectCheck   = "denied_subject_id"
+	tagBlacklistCheck    = "tag_blacklist"
+	tagWhitelistCheck    = "tag_whitelist"
+	platformCheck        = "platform"
+	airDateCheck         = "air_date"
+	collectionTotalCheck = "collection_total"
+)
+
+var subjectFilterChecks = []string{ratingCheck, deniedSubjectCheck, tagBlacklistCheck, tagWhitelistCheck, platformCheck, airDateCheck, collectionTotalCheck}
+
+// bangumi platform codes of anime subjects
+var animePlatforms = map[string]int64{
+	"TV":    1,
+	"OVA":   2,
+	"Movie": 3,
+	"Web":   5,
+}
+
+// SubjectFilterRules decides which subjects of a subject type are kept, an empty field does not filter anything
+type SubjectFilterRules struct {
+	TagBlacklist     []string `json:"tag_blacklist"`
+	TagWhitelist     []string `json:"tag_whitelist"` // subjects should have at least one of these tags
+	DeniedSubjectIds []string `json:"denied_subject_ids"`
+	// platform names (TV, OVA, Movie, Web for anime) or bangumi platform codes
+	Platforms          []string `json:"platforms"`
+	AirDateAfter       string   `json:"air_date_after"` // in util.SubjectDateFormat
+	AirDateBefore      string   `json:"air_date_before"`
+	MinCollectionTotal int64    `json:"min_collection_total"`
+}
+
+type subjectTypeFilter struct {
+	tagBlacklist       map[string]struct{}
+	tagWhitelist       map[string]struct{}
+	deniedSubjectIds   map[string]struct{}
+	platforms          map[int64]struct{}
+	airDateAfter       time.Time
+	airDateBefore      time.Time
+	minCollectionTotal int64
+	dropCounts         map[string]*atomic.Int64
+}
+
+// SubjectFilter decides which collections are kept, with the rules of the subject type of each collection
+// It is safe for concurrent use
+type SubjectFilter struct {
+	filters map[model.SubjectType]*subjectTypeFilter
+	// platform of the subjects known in db, as collections returned by the API do not tell the platform
+	subjectPlatforms map[string]int64
+}
+
+// LoadSubjectFilter reads the rules from a json file keyed by subject type name, or uses the default rules if path is empty
+func LoadSubjectFilter(path string) (*SubjectFilter, error) {
+	content := defaultSubjectFilterRules
+	if path != "" {
+		var err error
+		if content, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	var rulesByType map[string]SubjectFilterRules
+	if err := json.Unmarshal(content, &rulesByType); err != nil {
+		return nil, fmt.Errorf("failed to parse subject filter rules (%w)", err)
+	}
+
+	subjectFilter := &SubjectFilter{filters: make(map[model.SubjectType]*subjectTypeFilter, len(rulesByType))}
+	for subjectTypeStr, rules := range rulesByType {
+		subjectType, err := model.SubjectTypeFromString(subjectTypeStr)
+		if err != nil {
+			return nil, err
+		}
+		filter, err := newSubjectTypeFilter(subjectType, rules)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s subject filter rules (%w)", subjectTypeStr, err)
+		}
+		subjectFilter.filters[subjectType] = filter
+	}
+	return subjectFilter, nil
+}
+
+func newSubjectTypeFilter(subjectType model.SubjectType, rules SubjectFilterRules) (*subjectTypeFilter, error) {
+	filter := &subjectTypeFilter{
+		tagBlacklist:       toSet(rules.TagBlacklist),
+		tagWhitelist:       toSet(rules.TagWhitelist),
+		deniedSubjectIds:   toSet(rules.DeniedSubjectIds),
+		platforms:          make(map[int64]struct{}, len(rules.Platforms)),
+		minCollectionTotal: rules.MinCollectionTotal,
+		dropCounts:         make(map[string]*atomic.Int64, len(subjectFilterChecks)),
+	}
+	for _, check := range subjectFilterChecks {
+		filter.dropCounts[check] = &atomic.Int64{}
+	}
+
+	for _, platformStr := range rules.Platforms {
+		platform, ok := animePlatforms[platformStr]
+		if !ok || subjectType != model.Anime {
+			var err error
+			if platform, err = strconv.ParseInt(platformStr, 10, 64); err != nil {
+				return nil, fmt.Errorf("platform %s is not supported", platformStr)
+			}
+		}
+		filter.platforms[platform] = struct{}{}
+	}
+
+	var err error
+	if rules.AirDateAfter != "" {
+		if filter.airDateAfter, err = time.Parse(util.SubjectDateFormat, rules.AirDateAfter); err != nil {
+			return nil, err
+		}
+	}
+	if rules.AirDateBefore != "" {
+		if filter.airDateBefore, err = time.Parse(util.SubjectDateFormat, rules.AirDateBefore); err != nil {
+			return nil, err
+		}
+	}
+	return filter, nil
+}
+
+// NeedsSubjectPlatforms tells if SetSubjectPlatforms should be called before filtering
+func (subjectFilter *SubjectFilter) NeedsSubjectPlatforms() bool {
+	for _, filter := range subjectFilter.filters {
+		if len(filter.platforms) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// SetSubjectPlatforms should be called before filtering starts, collections of subjects with unknown platform are kept
+func (subjectFilter *SubjectFilter) SetSubjectPlatforms(subjectPlatforms map[string]int64) {
+	subjectFilter.subjectPlatforms = subjectPlatforms
+}
+
+// RatedFilter only keeps rated collections of the subjects accepted by the rules
+func (subjectFilter *SubjectFilter) RatedFilter(collection gjson.Result) bool {
+	if collection.Get("rate").Int() == 0 {
+		subjectFilter.drop(collection, ratingCheck)
+		return false
+	}
+	return subjectFilter.UnratedFilter(collection)
+}
+
+// UnratedFilter applies the same subject checks as RatedFilter but keeps collections without rating,
+// as most ToWatch, Watching and Postponed collections are never rated
+func (subjectFilter *SubjectFilter) UnratedFilter(collection gjson.Result) bool {
+	filter, ok := subjectFilter.filters[model.SubjectType(collection.Get("subject_type").Int())]
+	if !ok {
+		return true
+	}
+	subject := collection.Get("subject")
+
+	check := filter.rejectedBy(collection.Get("subject_id").String(), subject, subjectFilter.subjectPlatforms)
+	if check != "" {
+		filter.dropCounts[check].Add(1)
+		return false
+	}
+	return true
+}
+
+// CollectionFilter returns the filter applied to collections of the given type before they are persisted
+func (subjectFilter *SubjectFilter) CollectionFilter(ctype model.CollectionType) func(gjson.Result) bool {
+	if ctype == model.Watched {
+		return subjectFilter.RatedFilter
+	}
+	return subjectFilter.UnratedFilter
+}
+
+// LogDropCounts logs how many collections each check dropped
+func (subjectFilter *SubjectFilter) LogDropCounts() {
+	for subjectType, filter := range subjectFilter.filters {
+		event := log.Info().Str("subjectType", subjectType.String())
+		for _, check := range subjectFilterChecks {
+			event = event.Int64(check, filter.dropCounts[check].Load())
+		}
+		event.Msg("Collections dropped by subject filter")
+	}
+}
+
+func (subjectFilter *SubjectFilter) drop(collection gjson.Result, check string) {
+	if filter, ok := subjectFilter.filters[model.SubjectType(collection.Get("subject_type").Int())]; ok {
+		filter.dropCounts[check].Add(1)
+	}
+}
+
+// rejectedBy returns the first check the subject fails, or an empty string if it passes all of them
+func (filter *subjectTypeFilter) rejectedBy(sid string, subject gjson.Result, subjectPlatforms map[string]int64) string {
+	if _, ok := filter.deniedSubjectIds[sid]; ok {
+		return deniedSubjectCheck
+	}
+
+	tags := subject.Get("tags").Array()
+	whitelisted := len(filter.tagWhitelist) == 0
+	for _, tag := range tags {
+		tagName := tag.Get("name").String()
+		if _, ok := filter.tagBlacklist[tagName]; ok {
+			return tagBlacklistCheck
+		}
+		if _, ok := filter.tagWhitelist[tagName]; ok {
+			whitelisted = true
+		}
+	}
+	if !whitelisted {
+		return tagWhitelistCheck
+	}
+
+	if len(filter.platforms) > 0 {
+		if platform, ok := subjectPlatforms[sid]; ok {
+			if _, accepted := filter.platforms[platform]; !accepted {
+				return platformCheck
+			}
+		}
+	}
+
+	if !filter.airDateAfter.IsZero() || !filter.airDateBefore.IsZero() {
+		// subjects without a known air date are kept
+		if airDate, err := time.Parse(util.SubjectDateFormat, strings.TrimSpace(subject.Get("date").String())); err == nil {
+			if (!filter.airDateAfter.IsZero() && airDate.Before(filter.airDateAfter)) ||
+				(!filter.airDateBefore.IsZero() && airDate.After(filter.airDateBefore)) {
+				return airDateCheck
+			}
+		}
+	}
+
+	// assuming a subject with too few collections are not generally available
+	// meaning not watching it does not necessarily mean people are not interested in the work
+	if subject.Get("collection_total").Int() < filter.minCollectionTotal {
+		return collectionTotalCheck
+	}
+	return ""
+}
+
+func toSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		set[value] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,107 @@
+package helper
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AlcEccentric/beck-mizuki/dao"
+	"github.com/AlcEccentric/beck-mizuki/model"
+	"github.com/AlcEccentric/beck-mizuki/util"
+)
+
+// UserDataSource provides the collections of one user of one subject type that VIP rules are evaluated on
+// Rated collections are the ones accepted by SubjectFilter.RatedFilter
+type UserDataSource interface {
+	// Now is the time the evaluation is made at
+	Now() time.Time
+	CollectionCount(ctype model.CollectionType) (int, error)
+	// CollectionTime returns the time of the collection at offset, the newest collection being at offset 0
+	CollectionTime(ctype model.CollectionType, offset int) (time.Time, error)
+	RatedCollections(ctype model.CollectionType) ([]model.Collection, error)
+	RecentRatedCollections(ctype model.CollectionType, recentWindowInDays int) ([]model.Collection, error)
+}
+
+// apiUserDataSource fetches the data lazily from the API and caches it, so that rules sharing data do not query it twice
+// It is not safe for concurrent use, as a user is evaluated by one goroutine
+type apiUserDataSource struct {
+	bgmAPI           *dao.BgmApiAccessor
+	subjectFilter    *SubjectFilter
+	uid              string
+	subjectType      model.SubjectType
+	calendarLocation *time.Location
+	counts           map[model.CollectionType]int
+	times            map[string]time.Time
+	ratedCollections map[string][]model.Collection
+}
+
+// Recent windows are counted in calendar days of calendarLocation
+func NewApiUserDataSource(bgmAPI *dao.BgmApiAccessor, subjectFilter *SubjectFilter, uid string, subjectType model.SubjectType, calendarLocation *time.Location) UserDataSource {
+	return &apiUserDataSource{
+		bgmAPI:           bgmAPI,
+		subjectFilter:    subjectFilter,
+		uid:              uid,
+		subjectType:      subjectType,
+		calendarLocation: calendarLocation,
+		counts:           make(map[model.CollectionType]int),
+		times:            make(map[string]time.Time),
+		ratedCollections: make(map[string][]model.Collection),
+	}
+}
+
+func (source *apiUserDataSource) Now() time.Time {
+	return time.Now()
+}
+
+func (source *apiUserDataSource) CollectionCount(ctype model.CollectionType) (int, error) {
+	if count, ok := source.counts[ctype]; ok {
+		return count, nil
+	}
+	count, err := source.bgmAPI.GetCollectionCount(source.uid, ctype, source.subjectType)
+	if err != nil {
+		return 0, err
+	}
+	source.counts[ctype] = count
+	return count, nil
+}
+
+func (source *apiUserDataSource) CollectionTime(ctype model.CollectionType, offset int) (time.Time, error) {
+	key := fmt.Sprintf("%d/%d", ctype, offset)
+	if collectionTime, ok := source.times[key]; ok {
+		return collectionTime, nil
+	}
+	collectionTime, err := source.bgmAPI.GetCollectionTime(source.uid, offset, ctype, source.subjectType)
+	if err != nil {
+		return time.Time{}, err
+	}
+	source.times[key] = collectionTime
+	return collectionTime, nil
+}
+
+func (source *apiUserDataSource) RatedCollections(ctype model.CollectionType) ([]model.Collection, error) {
+	return source.getRatedCollections(ctype, 0)
+}
+
+func (source *apiUserDataSource) RecentRatedCollections(ctype model.CollectionType, recentWindowInDays int) ([]model.Collection, error) {
+	return source.getRatedCollections(ctype, recentWindowInDays)
+}
+
+func (source *apiUserDataSource) getRatedCollections(ctype model.CollectionType, recentWindowInDays int) ([]model.Collection, error) {
+	key := fmt.Sprintf("%d/%d", ctype, recentWindowInDays)
+	if collections, ok := source.ratedCollections[key]; ok {
+		return collections, nil
+	}
+
+	var collections []model.Collection
+	var err error
+	if recentWindowInDays > 0 {
+		since := util.CalendarWindowStart(source.Now(), recentWindowInDays, source.calendarLocation)
+		collections, err = source.bgmAPI.GetRecentCollections(source.uid, ctype, source.subjectType, source.subjectFilter.RatedFilter, since)
+	} else {
+		collections, err = source.bgmAPI.GetCollections(source.uid, ctype, source.subjectType, source.subjectFilter.RatedFilter)
+	}
+	if err != nil {
+		return nil, err
+	}
+	source.ratedCollections[key] = collections
+	return collections, nil
+}
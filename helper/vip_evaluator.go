@@ -1,41 +1,53 @@
 package helper
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/AlcEccentric/beck-mizuki/dao"
 	"github.com/AlcEccentric/beck-mizuki/model"
-	"github.com/AlcEccentric/beck-mizuki/util"
-	"github.com/rs/zerolog/log"
-	"github.com/tidwall/gjson"
 )
 
-// Reject users with watched count less than T1WatchedCnt
-// Reject users whose oldest watched collection was made more than last MinOldestWatchedAgeInDays days ago
-// For users with watched count in [T1WatchedCnt, T2WatchedCnt), they should have at least 1 watched every T1IntervalDays in the past ActivityCheckDays
-// For users with watched count in [T2WatchedCnt, T3WatchedCnt), they should have at least 1 watched every T2IntervalDays in the past ActivityCheckDays
-// For users with watched count in [T3WatchedCnt, inf), they should have at least 1 collection every T3IntervalDays in the past ActivityCheckDays
-// If user does not meet the criteria for watched, they should have at least MinWatchingCnt in the past ActivityCheckDays
-// For each user, up to NonWatchedIntervalTolerance periods are allowed without a collection
-// Reject users with less than MinFilteredWatchedCnt collections
-
-var tagsToReject = map[string]struct{}{
-	"国产":   {},
-	"国产动画": {},
-	"中国":   {},
-	"欧美":   {},
-	"美国":   {},
-	"童年":   {},
-	"短片":   {},
-	"PV":   {},
-	"民工":   {},
-	"MV":   {},
+// VipEvaluator tells if a user is a VIP by evaluating the rule set of a subject type, see vip_rules.go for the rules
+type VipEvaluator struct {
+	bgmAPI         *dao.BgmApiAccessor
+	konomiAccessor dao.KonomiAccessor
+	ruleSets       map[model.SubjectType][]Rule
+	activityRule   Rule
+	thresholds     VipThresholds
+	qualityModel   QualityModel
+	subjectFilter  *SubjectFilter
+	// rejected candidates are not evaluated again within the cooldown, unless their failing metric may have changed
+	reevaluationCooldown time.Duration
 }
 
-func IsVip(uid string, bgmAPI *dao.BgmApiAccessor, konomiAccessor dao.KonomiAccessor) (bool, []model.Collection) {
+func NewVipEvaluator(bgmAPI *dao.BgmApiAccessor, konomiAccessor dao.KonomiAccessor, ruleSets map[model.SubjectType][]Rule, thresholds VipThresholds,
+	reevaluationCooldown time.Duration, subjectFilter *SubjectFilter) *VipEvaluator {
+	return &VipEvaluator{
+		bgmAPI:               bgmAPI,
+		konomiAccessor:       konomiAccessor,
+		ruleSets:             ruleSets,
+		activityRule:         ActivityRule{thresholds: thresholds},
+		thresholds:           thresholds,
+		qualityModel:         DefaultQualityModel(thresholds),
+		reevaluationCooldown: reevaluationCooldown,
+		subjectFilter:        subjectFilter,
+	}
+}
+
+// SubjectFilter returns the filter rated collections are evaluated on, to be reused when persisting collections
+func (evaluator *VipEvaluator) SubjectFilter() *SubjectFilter {
+	return evaluator.subjectFilter
+}
+
+// CalendarLocation is the zone calendar days are counted in
+func (evaluator *VipEvaluator) CalendarLocation() *time.Location {
+	return evaluator.thresholds.CalendarLocation
+}
 
-	_, err := konomiAccessor.GetUser(uid)
-	if err == nil {
+// IsVip evaluates a user on anime, which is the subject type users are collected for
+func (evaluator *VipEvaluator) IsVip(uid string) EvaluationResult {
+	if _, err := evaluator.konomiAccessor.GetUser(uid); err == nil {
 		// Any existing user is considered as vip
 		// This is because:
 		// 1. The first run should have checked non-activity related criteria for the user
@@ -43,114 +55,133 @@ func IsVip(uid string, bgmAPI *dao.BgmApiAccessor, konomiAccessor dao.KonomiAcce
 		// That said, remaining users can be approximately considered as VIP users
 		// (I say "approximately" because few users might become inactive between this cold start run and the last regular update run.
 		// As long as the interval of regular update is not too long (like >0.5 activity check window), this should be fine.)
-		return true, nil
-	}
-
-	// raw watched collection count test
-	rawWatchedCount, err := bgmAPI.GetCollectionCount(uid, model.Watched, model.Anime)
-
-	if err != nil {
-		log.Error().Err(err).Msgf("Failed to get watched collection count for user: %s. Skipping.", uid)
-		return false, nil
-	}
-
-	if rawWatchedCount < util.T1WatchedCnt {
-		log.Debug().Msgf("Ignore user: %s because raw collection count was under %d", uid, util.T1WatchedCnt)
-		return false, nil
-	}
-
-	// earlist watched collection time test
-	earliestWatchedTime, err := bgmAPI.GetCollectionTime(uid, rawWatchedCount-1, model.Watched, model.Anime)
-
-	if err != nil {
-		log.Error().Err(err).Msgf("Failed to get earliest watched collection time for user: %s. Skipping.", uid)
-		return false, nil
-	}
-
-	if time.Since(earliestWatchedTime) < time.Hour*24*util.MinOldestWatchedAgeInDays {
-		log.Debug().Msgf("Ignore user: %s because earliest watched collection time was under %d days from today", uid, util.MinOldestWatchedAgeInDays)
-		return false, nil
-	}
-
-	// leveled activity test
-	isActive := IsActive(bgmAPI, uid, rawWatchedCount)
-	if !isActive {
-		log.Debug().Msgf("Ignore user: %s because not considered active", uid)
-		return false, nil
+		return EvaluationResult{UserID: uid, SubjectType: model.Anime, Passed: true, Source: NewApiUserDataSource(evaluator.bgmAPI, evaluator.subjectFilter, uid, model.Anime, evaluator.thresholds.CalendarLocation)}
 	}
+	return evaluator.Evaluate(uid, model.Anime)
+}
 
-	// filtered watched count check
-	filteredWatched, err := bgmAPI.GetCollections(uid, model.Watched, model.Anime, AnimeFilter)
-	if err != nil {
-		log.Error().Err(err).Msgf("Failed to get filtered watched collections for user: %s. Skipping.", uid)
-		return false, nil
+// Evaluate runs the rules of the subject type in order and stops at the first rule that fails or errors
+func (evaluator *VipEvaluator) Evaluate(uid string, subjectType model.SubjectType) EvaluationResult {
+	ctx := &EvaluationContext{
+		UserID:      uid,
+		SubjectType: subjectType,
+		Source:      NewApiUserDataSource(evaluator.bgmAPI, evaluator.subjectFilter, uid, subjectType, evaluator.thresholds.CalendarLocation),
 	}
-
-	if len(filteredWatched) < util.MinFilteredWatchedCnt {
-		log.Debug().Msgf("Ignore user: %s because filtered watched collection count was under %d", uid, util.MinFilteredWatchedCnt)
-		return false, nil
+	rules, ok := evaluator.ruleSets[subjectType]
+	if !ok {
+		return EvaluationResult{
+			UserID:      uid,
+			SubjectType: subjectType,
+			Source:      ctx.Source,
+			Err:         fmt.Errorf("no vip rule set for subject type %s", subjectType.String()),
+		}
 	}
-
-	// Return filtered watched to reduce the number of API calls
-	return true, filteredWatched
+	return EvaluateRules(rules, ctx, true)
 }
 
-func AnimeFilter(animeCol gjson.Result) bool {
-	tags := animeCol.Get("subject").Get("tags").Array()
-	for _, tag := range tags {
-		if _, ok := tagsToReject[tag.Get("name").String()]; ok {
-			return false
+// EvaluateRules runs the rules in order, and stops at the first rule that fails if stopAtFailure is set
+// FailedRule is the first failed rule, and evaluation always stops at the first rule that errors
+func EvaluateRules(rules []Rule, ctx *EvaluationContext, stopAtFailure bool) EvaluationResult {
+	result := EvaluationResult{UserID: ctx.UserID, SubjectType: ctx.SubjectType, Source: ctx.Source}
+	for _, rule := range rules {
+		ruleResult := rule.Evaluate(ctx)
+		result.Results = append(result.Results, ruleResult)
+		if ruleResult.Err != nil {
+			result.Err = fmt.Errorf("failed to evaluate rule %s for user %s (%w)", rule.Name(), ctx.UserID, ruleResult.Err)
+			return result
+		}
+		if !ruleResult.Passed && result.FailedRule == "" {
+			result.FailedRule = rule.Name()
+			if stopAtFailure {
+				return result
+			}
 		}
 	}
-	// only accept collection with rating
-	rating := int(animeCol.Get("rate").Int())
-	if rating == 0 {
-		return false
-	}
-	collectionTotal := animeCol.Get("subject").Get("collection_total").Int()
-	// assuming a subject with too few collections are not generally available
-	// meaning not watching it does not necessarily mean people are not interested in the work
-	return collectionTotal >= util.SubjectMinCollectionCnt
+	result.Passed = result.FailedRule == ""
+	return result
 }
 
-func IsActive(bgmAPI *dao.BgmApiAccessor, uid string, rawWatchedCount int) bool {
-	recentWatched, err := bgmAPI.GetRecentCollections(uid, model.Watched, model.Anime, AnimeFilter, util.ActivityCheckDays)
-	if err != nil {
-		log.Error().Err(err).Msgf("Failed to get recent watched collections for user: %s. Skipping.", uid)
-		return false
+// QualityScore returns the quality score of an evaluated user, reusing the score measured by the quality score rule if any
+func (evaluator *VipEvaluator) QualityScore(result EvaluationResult) (float64, error) {
+	for _, ruleResult := range result.Results {
+		if ruleResult.Rule == QualityScoreRuleName && ruleResult.Err == nil {
+			return ruleResult.Measured, nil
+		}
 	}
+	score, _, err := evaluator.qualityModel.Score(result.Source, 0)
+	return score, err
+}
 
-	if rawWatchedCount < util.T2WatchedCnt {
-		return isActiveWatched(recentWatched, util.T1IntervalDays) || isActiveWatching(bgmAPI, uid)
-	} else if rawWatchedCount < util.T3WatchedCnt {
-		return isActiveWatched(recentWatched, util.T2IntervalDays) || isActiveWatching(bgmAPI, uid)
-	} else {
-		return isActiveWatched(recentWatched, util.T3IntervalDays) || isActiveWatching(bgmAPI, uid)
-	}
+// UserQualityScore returns the quality score of a user that was not evaluated, e.g. a user already persisted
+func (evaluator *VipEvaluator) UserQualityScore(uid string) (float64, error) {
+	source := NewApiUserDataSource(evaluator.bgmAPI, evaluator.subjectFilter, uid, model.Anime, evaluator.thresholds.CalendarLocation)
+	score, _, err := evaluator.qualityModel.Score(source, 0)
+	return score, err
 }
 
-func isActiveWatched(collections []model.Collection, intervalDays int) bool {
-	lastIntervalIdx := -1
-	toleranceCounter := util.NonWatchedIntervalTolerance
-	for i := 0; i < len(collections); i++ {
-		curIntervalIdx := int(time.Since(collections[i].CollectedTime).Hours()) / (24 * intervalDays)
-		toleranceCounter -= ((curIntervalIdx - lastIntervalIdx) - 1)
+// DetectBots runs the bot detectors of the anime rule set on the given collections, e.g. the ones fetched while updating a user
+func (evaluator *VipEvaluator) DetectBots(uid string, collections []model.Collection) EvaluationResult {
+	return EvaluateRules(BotDetectors(evaluator.ruleSets[model.Anime]), &EvaluationContext{
+		UserID:      uid,
+		SubjectType: model.Anime,
+		Source:      NewHistoryUserDataSource(collections, model.Anime, time.Now(), evaluator.thresholds.CalendarLocation),
+	}, true)
+}
 
-		if toleranceCounter < 0 {
-			return false
+// ShouldReevaluate tells if a candidate evaluated before is worth evaluating again
+func (evaluator *VipEvaluator) ShouldReevaluate(evaluation model.CandidateEvaluation, now time.Time) bool {
+	elapsed := now.Sub(evaluation.EvaluatedAt)
+	if evaluation.Passed || elapsed >= evaluator.reevaluationCooldown {
+		return true
+	}
+	for _, rule := range evaluator.ruleSets[evaluation.SubjectType] {
+		if rule.Name() != evaluation.FailedRule {
+			continue
+		}
+		if hinter, ok := rule.(ChangeHinter); ok {
+			return hinter.MayHaveChanged(evaluation.Measured[rule.Name()], ruleDetails(evaluation.Measured, rule.Name()), elapsed)
 		}
-		lastIntervalIdx = curIntervalIdx
+		return false
 	}
+	// the failed rule is no longer configured
 	return true
 }
 
-func isActiveWatching(bgmAPI *dao.BgmApiAccessor, uid string) bool {
-	watching, err := bgmAPI.GetRecentCollections(uid, model.Watching, model.Anime, AnimeFilter, util.ActivityCheckDays)
+// IsActive only runs the activity rule, as the other rules always pass for existing users
+func (evaluator *VipEvaluator) IsActive(uid string) EvaluationResult {
+	return EvaluateRules([]Rule{evaluator.activityRule}, &EvaluationContext{
+		UserID:      uid,
+		SubjectType: model.Anime,
+		Source:      NewApiUserDataSource(evaluator.bgmAPI, evaluator.subjectFilter, uid, model.Anime, evaluator.thresholds.CalendarLocation),
+	}, true)
+}
 
+// UserEvaluation returns the tier and activity of an evaluated user, reusing what the evaluation already measured
+func (evaluator *VipEvaluator) UserEvaluation(result EvaluationResult, filteredWatchedCount int) (model.UserEvaluation, error) {
+	rawWatchedCount, err := result.Source.CollectionCount(model.Watched)
 	if err != nil {
-		log.Error().Err(err).Msgf("Failed to get recent watching collections for user: %s. Skipping.", uid)
-		return false
+		return model.UserEvaluation{}, err
+	}
+
+	activityResult, found := RuleResult{}, false
+	for _, ruleResult := range result.Results {
+		if ruleResult.Rule == ActivityRuleName {
+			activityResult, found = ruleResult, true
+		}
+	}
+	if !found {
+		activityResult = evaluator.activityRule.Evaluate(&EvaluationContext{UserID: result.UserID, SubjectType: result.SubjectType, Source: result.Source})
+	}
+	if activityResult.Err != nil {
+		return model.UserEvaluation{}, activityResult.Err
 	}
 
-	return len(watching) >= util.MinWatchingCnt
+	return model.UserEvaluation{
+		UserID:               result.UserID,
+		Tier:                 evaluator.thresholds.Tier(rawWatchedCount),
+		RawWatchedCount:      rawWatchedCount,
+		FilteredWatchedCount: filteredWatchedCount,
+		ActivityGap:          activityResult.Measured,
+		EvaluatedAt:          result.Source.Now(),
+	}, nil
 }
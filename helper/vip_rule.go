@@ -0,0 +1,241 @@
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AlcEccentric/beck-mizuki/model"
+	"github.com/AlcEccentric/beck-mizuki/util"
+)
+
+// VipThresholds are the thresholds the VIP rules compare users against
+type VipThresholds struct {
+	MinOldestWatchedAgeInDays   int
+	T1WatchedCnt                int
+	T2WatchedCnt                int
+	T3WatchedCnt                int
+	MinWatchingCnt              int
+	ActivityCheckDays           int
+	T1IntervalDays              int
+	T2IntervalDays              int
+	T3IntervalDays              int
+	NonWatchedIntervalTolerance int
+	MinFilteredWatchedCnt       int
+	// activity, see activity_model.go
+	ActivityModel                string
+	ActivityHalfLifeDays         float64
+	MinDecayedActivityScore      float64
+	MinPoissonWatchProbability   float64
+	MinPoissonSilenceProbability float64
+	// activity windows and intervals are counted in calendar days of this zone
+	CalendarLocation *time.Location `json:"-"`
+	// upper bound of the watched collections a user plausibly adds in a day, faster users are caught by WatchVelocityRule
+	MaxWatchedPerDay int
+	// average watched collections a dedicated user adds per day over months, used to tell if a failed count may have changed
+	SustainedWatchedPerDay float64
+	MinQualityScore        float64
+	// bot detection, see bot_detectors.go
+	BurstWindowMinutes    int
+	BurstMaxCollections   int
+	UniformRatingMinRated int
+	UniformRatingMaxShare float64
+	VelocityWindowDays    int
+}
+
+func DefaultVipThresholds() VipThresholds {
+	return VipThresholds{
+		MinOldestWatchedAgeInDays:    util.MinOldestWatchedAgeInDays,
+		T1WatchedCnt:                 util.T1WatchedCnt,
+		T2WatchedCnt:                 util.T2WatchedCnt,
+		T3WatchedCnt:                 util.T3WatchedCnt,
+		MinWatchingCnt:               util.MinWatchingCnt,
+		ActivityCheckDays:            util.ActivityCheckDays,
+		T1IntervalDays:               util.T1IntervalDays,
+		T2IntervalDays:               util.T2IntervalDays,
+		T3IntervalDays:               util.T3IntervalDays,
+		NonWatchedIntervalTolerance:  util.NonWatchedIntervalTolerance,
+		MinFilteredWatchedCnt:        util.MinFilteredWatchedCnt,
+		ActivityModel:                util.ActivityModel,
+		ActivityHalfLifeDays:         util.ActivityHalfLifeDays,
+		MinDecayedActivityScore:      util.MinDecayedActivityScore,
+		MinPoissonWatchProbability:   util.MinPoissonWatchProbability,
+		MinPoissonSilenceProbability: util.MinPoissonSilenceProbability,
+		CalendarLocation:             util.DefaultCalendarLocation,
+		MaxWatchedPerDay:             util.MaxWatchedPerDay,
+		SustainedWatchedPerDay:       util.SustainedWatchedPerDay,
+		MinQualityScore:              util.MinQualityScore,
+		BurstWindowMinutes:           util.BurstWindowMinutes,
+		BurstMaxCollections:          util.BurstMaxCollections,
+		UniformRatingMinRated:        util.UniformRatingMinRated,
+		UniformRatingMaxShare:        util.UniformRatingMaxShare,
+		VelocityWindowDays:           util.VelocityWindowDays,
+	}
+}
+
+// Tier returns 0 for users under T1WatchedCnt, then 1, 2 or 3 for users from T1WatchedCnt, T2WatchedCnt or T3WatchedCnt on
+func (thresholds VipThresholds) Tier(rawWatchedCount int) int {
+	switch {
+	case rawWatchedCount >= thresholds.T3WatchedCnt:
+		return 3
+	case rawWatchedCount >= thresholds.T2WatchedCnt:
+		return 2
+	case rawWatchedCount >= thresholds.T1WatchedCnt:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// IntervalDays returns the interval users of the tier should watch something in
+func (thresholds VipThresholds) IntervalDays(tier int) int {
+	switch tier {
+	case 3:
+		return thresholds.T3IntervalDays
+	case 2:
+		return thresholds.T2IntervalDays
+	default:
+		return thresholds.T1IntervalDays
+	}
+}
+
+// LoadThresholdSets reads named threshold sets from a json file, each set overriding some fields of base
+// e.g. {"lenient": {"T1WatchedCnt": 300, "NonWatchedIntervalTolerance": 4}, "decay": {"ActivityModel": "decay"}}
+func LoadThresholdSets(path string, base VipThresholds) (map[string]VipThresholds, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var overrides map[string]json.RawMessage
+	if err := json.Unmarshal(content, &overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse threshold sets (%w)", err)
+	}
+
+	thresholdSets := make(map[string]VipThresholds, len(overrides))
+	for name, override := range overrides {
+		thresholds := base
+		if err := json.Unmarshal(override, &thresholds); err != nil {
+			return nil, fmt.Errorf("failed to parse threshold set %s (%w)", name, err)
+		}
+		thresholdSets[name] = thresholds
+	}
+	return thresholdSets, nil
+}
+
+// EvaluationContext is what a rule is evaluated on
+type EvaluationContext struct {
+	UserID      string
+	SubjectType model.SubjectType
+	Source      UserDataSource
+}
+
+// Rule is one criterion a user must meet to be a VIP
+type Rule interface {
+	Name() string
+	Evaluate(ctx *EvaluationContext) RuleResult
+}
+
+// ChangeHinter is implemented by rules that can tell if a past failure may no longer hold without querying the user again
+// details are the ones of the failed result, see RuleResult
+type ChangeHinter interface {
+	MayHaveChanged(measured float64, details map[string]float64, elapsed time.Duration) bool
+}
+
+type RuleResult struct {
+	Rule     string
+	Passed   bool
+	Reason   string
+	Measured float64 // the value compared against the threshold
+	// Details are the values Measured was computed from that a ChangeHinter needs, stored in the ledger with it
+	Details map[string]float64
+	// Flagged is set if a bot detector failed but bots are only flagged, in which case Passed is set too
+	Flagged bool
+	// Err is set if the data could not be fetched, in which case the user is neither accepted nor rejected
+	Err error
+}
+
+// EvaluationResult tells whether a user is a VIP and why
+type EvaluationResult struct {
+	UserID      string
+	SubjectType model.SubjectType
+	Passed      bool
+	FailedRule  string // empty if passed or if the evaluation errored
+	Results     []RuleResult
+	Err         error
+	// Source holds the data fetched during the evaluation, so that callers can reuse it instead of querying it again
+	Source UserDataSource
+}
+
+// detailSeparator joins a rule name and the name of one of its details in the measured values of the ledger
+const detailSeparator = "."
+
+// ruleDetails returns the details of the rule stored in the measured values of the ledger
+func ruleDetails(measured map[string]float64, ruleName string) map[string]float64 {
+	details := make(map[string]float64)
+	for name, value := range measured {
+		if detailName, found := strings.CutPrefix(name, ruleName+detailSeparator); found {
+			details[detailName] = value
+		}
+	}
+	return details
+}
+
+// ToCandidateEvaluation returns the evaluation to store in the candidate ledger
+func (result EvaluationResult) ToCandidateEvaluation(evaluatedAt time.Time) model.CandidateEvaluation {
+	measured := make(map[string]float64, len(result.Results))
+	for _, ruleResult := range result.Results {
+		measured[ruleResult.Rule] = ruleResult.Measured
+		for name, value := range ruleResult.Details {
+			measured[ruleResult.Rule+detailSeparator+name] = value
+		}
+	}
+	return model.CandidateEvaluation{
+		UserID:      result.UserID,
+		SubjectType: result.SubjectType,
+		Passed:      result.Passed,
+		FailedRule:  result.FailedRule,
+		Measured:    measured,
+		EvaluatedAt: evaluatedAt,
+	}
+}
+
+// Flags returns the reasons the user was flagged for
+func (result EvaluationResult) Flags() []string {
+	flags := make([]string, 0)
+	for _, ruleResult := range result.Results {
+		if ruleResult.Flagged {
+			flags = append(flags, ruleResult.Rule+": "+ruleResult.Reason)
+		}
+	}
+	return flags
+}
+
+func (result EvaluationResult) String() string {
+	outcomes := make([]string, 0, len(result.Results))
+	for _, ruleResult := range result.Results {
+		outcome := "passed"
+		if ruleResult.Flagged {
+			outcome = "flagged"
+		} else if ruleResult.Err != nil {
+			outcome = "errored"
+		} else if !ruleResult.Passed {
+			outcome = "failed"
+		}
+		outcomes = append(outcomes, fmt.Sprintf("%s %s (%s)", ruleResult.Rule, outcome, ruleResult.Reason))
+	}
+	return fmt.Sprintf("user %s %s evaluation: %s", result.UserID, result.SubjectType.String(), strings.Join(outcomes, "; "))
+}
+
+func passed(rule Rule, measured float64, reasonFormat string, args ...any) RuleResult {
+	return RuleResult{Rule: rule.Name(), Passed: true, Measured: measured, Reason: fmt.Sprintf(reasonFormat, args...)}
+}
+
+func failed(rule Rule, measured float64, reasonFormat string, args ...any) RuleResult {
+	return RuleResult{Rule: rule.Name(), Passed: false, Measured: measured, Reason: fmt.Sprintf(reasonFormat, args...)}
+}
+
+func errored(rule Rule, err error, reasonFormat string, args ...any) RuleResult {
+	return RuleResult{Rule: rule.Name(), Err: err, Reason: fmt.Sprintf(reasonFormat, args...)}
+}
@@ -0,0 +1,232 @@
+package helper
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AlcEccentric/beck-mizuki/model"
+)
+
+const (
+	RawWatchedCountRuleName      = "raw_watched_count"
+	OldestWatchedAgeRuleName     = "oldest_watched_age"
+	ActivityRuleName             = "activity"
+	FilteredWatchedCountRuleName = "filtered_watched_count"
+	QualityScoreRuleName         = "quality_score"
+)
+
+func NewRule(name string, thresholds VipThresholds) (Rule, error) {
+	switch name {
+	case RawWatchedCountRuleName:
+		return RawWatchedCountRule{thresholds: thresholds}, nil
+	case OldestWatchedAgeRuleName:
+		return OldestWatchedAgeRule{thresholds: thresholds}, nil
+	case ActivityRuleName:
+		// fail on an unknown activity model when the rule is built rather than on every evaluation
+		if _, err := NewActivityModel(thresholds); err != nil {
+			return nil, err
+		}
+		return ActivityRule{thresholds: thresholds}, nil
+	case FilteredWatchedCountRuleName:
+		return FilteredWatchedCountRule{thresholds: thresholds}, nil
+	case BurstImportRuleName:
+		return BurstImportRule{thresholds: thresholds}, nil
+	case UniformRatingRuleName:
+		return UniformRatingRule{thresholds: thresholds}, nil
+	case WatchVelocityRuleName:
+		return WatchVelocityRule{thresholds: thresholds}, nil
+	case QualityScoreRuleName:
+		return QualityScoreRule{thresholds: thresholds, qualityModel: DefaultQualityModel(thresholds)}, nil
+	default:
+		return nil, fmt.Errorf("vip rule %s is not supported", name)
+	}
+}
+
+func RuleNames(rules []Rule) []string {
+	names := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		names = append(names, rule.Name())
+	}
+	return names
+}
+
+// NewRuleSets builds the rules of each subject type from their names
+func NewRuleSets(ruleNames map[model.SubjectType][]string, thresholds VipThresholds) (map[model.SubjectType][]Rule, error) {
+	ruleSets := make(map[model.SubjectType][]Rule, len(ruleNames))
+	for subjectType, names := range ruleNames {
+		rules := make([]Rule, 0, len(names))
+		for _, name := range names {
+			rule, err := NewRule(strings.TrimSpace(name), thresholds)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, rule)
+		}
+		ruleSets[subjectType] = rules
+	}
+	return ruleSets, nil
+}
+
+// RawWatchedCountRule rejects users with watched count less than T1WatchedCnt
+type RawWatchedCountRule struct {
+	thresholds VipThresholds
+}
+
+func (rule RawWatchedCountRule) Name() string {
+	return RawWatchedCountRuleName
+}
+
+// MayHaveChanged tells if the user could have caught up watching at a sustained pace since
+func (rule RawWatchedCountRule) MayHaveChanged(measured float64, details map[string]float64, elapsed time.Duration) bool {
+	return measured+elapsedDays(elapsed)*rule.thresholds.SustainedWatchedPerDay >= float64(rule.thresholds.T1WatchedCnt)
+}
+
+func (rule RawWatchedCountRule) Evaluate(ctx *EvaluationContext) RuleResult {
+	rawWatchedCount, err := ctx.Source.CollectionCount(model.Watched)
+	if err != nil {
+		return errored(rule, err, "failed to get watched collection count")
+	}
+	if rawWatchedCount < rule.thresholds.T1WatchedCnt {
+		return failed(rule, float64(rawWatchedCount), "raw watched count %d is under %d", rawWatchedCount, rule.thresholds.T1WatchedCnt)
+	}
+	return passed(rule, float64(rawWatchedCount), "raw watched count %d", rawWatchedCount)
+}
+
+// OldestWatchedAgeRule rejects users whose oldest watched collection was made in the last MinOldestWatchedAgeInDays days
+type OldestWatchedAgeRule struct {
+	thresholds VipThresholds
+}
+
+func (rule OldestWatchedAgeRule) Name() string {
+	return OldestWatchedAgeRuleName
+}
+
+// MayHaveChanged tells if the earliest watched collection has grown old enough since
+func (rule OldestWatchedAgeRule) MayHaveChanged(measured float64, details map[string]float64, elapsed time.Duration) bool {
+	return measured+elapsedDays(elapsed) >= float64(rule.thresholds.MinOldestWatchedAgeInDays)
+}
+
+func (rule OldestWatchedAgeRule) Evaluate(ctx *EvaluationContext) RuleResult {
+	rawWatchedCount, err := ctx.Source.CollectionCount(model.Watched)
+	if err != nil {
+		return errored(rule, err, "failed to get watched collection count")
+	}
+	if rawWatchedCount == 0 {
+		return failed(rule, 0, "no watched collection")
+	}
+	earliestWatchedTime, err := ctx.Source.CollectionTime(model.Watched, rawWatchedCount-1)
+	if err != nil {
+		return errored(rule, err, "failed to get earliest watched collection time")
+	}
+
+	ageInDays := ctx.Source.Now().Sub(earliestWatchedTime).Hours() / 24
+	if ageInDays < float64(rule.thresholds.MinOldestWatchedAgeInDays) {
+		return failed(rule, ageInDays, "earliest watched collection is %.0f days old, under %d days", ageInDays, rule.thresholds.MinOldestWatchedAgeInDays)
+	}
+	return passed(rule, ageInDays, "earliest watched collection is %.0f days old", ageInDays)
+}
+
+// ActivityRule checks the user kept watching in the past ActivityCheckDays, as defined by the ActivityModel of the thresholds
+// If user is not active by the model, they should have at least MinWatchingCnt watching in the past ActivityCheckDays
+type ActivityRule struct {
+	thresholds VipThresholds
+}
+
+func (rule ActivityRule) Name() string {
+	return ActivityRuleName
+}
+
+// MayHaveChanged tells if a new interval started since, as any activity model needs new watched collections to change its mind
+func (rule ActivityRule) MayHaveChanged(measured float64, details map[string]float64, elapsed time.Duration) bool {
+	shortestIntervalDays := min(rule.thresholds.T1IntervalDays, rule.thresholds.T2IntervalDays, rule.thresholds.T3IntervalDays)
+	return elapsedDays(elapsed) >= float64(shortestIntervalDays)
+}
+
+func (rule ActivityRule) Evaluate(ctx *EvaluationContext) RuleResult {
+	activityModel, err := NewActivityModel(rule.thresholds)
+	if err != nil {
+		return errored(rule, err, "failed to get activity model")
+	}
+	rawWatchedCount, err := ctx.Source.CollectionCount(model.Watched)
+	if err != nil {
+		return errored(rule, err, "failed to get watched collection count")
+	}
+	recentWatched, err := ctx.Source.RecentRatedCollections(model.Watched, rule.thresholds.ActivityCheckDays)
+	if err != nil {
+		return errored(rule, err, "failed to get recent watched collections")
+	}
+
+	activity := activityModel.Measure(ctx.Source.Now(), rawWatchedCount, recentWatched)
+	if activity.Active {
+		return passed(rule, activity.Measured, "%s", activity.Reason)
+	}
+
+	recentWatching, err := ctx.Source.RecentRatedCollections(model.Watching, rule.thresholds.ActivityCheckDays)
+	if err != nil {
+		return errored(rule, err, "failed to get recent watching collections")
+	}
+	if len(recentWatching) >= rule.thresholds.MinWatchingCnt {
+		return passed(rule, activity.Measured, "%s but has %d recent watching", activity.Reason, len(recentWatching))
+	}
+	return failed(rule, activity.Measured, "%s and has %d recent watching (under %d)", activity.Reason, len(recentWatching), rule.thresholds.MinWatchingCnt)
+}
+
+// FilteredWatchedCountRule rejects users with less than MinFilteredWatchedCnt rated watched collections
+type FilteredWatchedCountRule struct {
+	thresholds VipThresholds
+}
+
+func (rule FilteredWatchedCountRule) Name() string {
+	return FilteredWatchedCountRuleName
+}
+
+// MayHaveChanged tells if the user could have caught up watching at a sustained pace since
+func (rule FilteredWatchedCountRule) MayHaveChanged(measured float64, details map[string]float64, elapsed time.Duration) bool {
+	return measured+elapsedDays(elapsed)*rule.thresholds.SustainedWatchedPerDay >= float64(rule.thresholds.MinFilteredWatchedCnt)
+}
+
+func (rule FilteredWatchedCountRule) Evaluate(ctx *EvaluationContext) RuleResult {
+	filteredWatched, err := ctx.Source.RatedCollections(model.Watched)
+	if err != nil {
+		return errored(rule, err, "failed to get filtered watched collections")
+	}
+	if len(filteredWatched) < rule.thresholds.MinFilteredWatchedCnt {
+		return failed(rule, float64(len(filteredWatched)), "filtered watched count %d is under %d", len(filteredWatched), rule.thresholds.MinFilteredWatchedCnt)
+	}
+	return passed(rule, float64(len(filteredWatched)), "filtered watched count %d", len(filteredWatched))
+}
+
+// QualityScoreRule rejects users with quality score less than MinQualityScore, see QualityModel
+type QualityScoreRule struct {
+	thresholds   VipThresholds
+	qualityModel QualityModel
+}
+
+func (rule QualityScoreRule) Name() string {
+	return QualityScoreRuleName
+}
+
+// MayHaveChanged tells if the most each component could have gained since lifts the score to MinQualityScore
+func (rule QualityScoreRule) MayHaveChanged(measured float64, details map[string]float64, elapsed time.Duration) bool {
+	return measured+rule.qualityModel.MaxGain(details, elapsedDays(elapsed)) >= rule.thresholds.MinQualityScore
+}
+
+func (rule QualityScoreRule) Evaluate(ctx *EvaluationContext) RuleResult {
+	score, components, err := rule.qualityModel.Score(ctx.Source, rule.thresholds.MinQualityScore)
+	if err != nil {
+		return errored(rule, err, "failed to compute quality score")
+	}
+	var result RuleResult
+	if score < rule.thresholds.MinQualityScore {
+		result = failed(rule, score, "quality score is at most %.3f, under %.3f", score, rule.thresholds.MinQualityScore)
+	} else {
+		result = passed(rule, score, "quality score %.3f", score)
+	}
+	result.Details = components
+	return result
+}
+
+func elapsedDays(elapsed time.Duration) float64 {
+	return elapsed.Hours() / 24
+}
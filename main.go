@@ -2,10 +2,14 @@ package main
 
 import (
 	"os"
+	"time"
 
 	"github.com/AlcEccentric/beck-mizuki/dao"
+	"github.com/AlcEccentric/beck-mizuki/helper"
+	"github.com/AlcEccentric/beck-mizuki/model"
 	"github.com/AlcEccentric/beck-mizuki/orch"
 	"github.com/AlcEccentric/beck-mizuki/param"
+	"github.com/AlcEccentric/beck-mizuki/scraper"
 	"github.com/AlcEccentric/beck-mizuki/util"
 	"github.com/joho/godotenv"
 	"github.com/rs/zerolog"
@@ -22,19 +26,63 @@ func main() {
 		log.Fatal().Err(err).Msg("Error loading .env file")
 	}
 
+	params := param.GetParams()
+	if params.ProxyPool != nil {
+		params.ProxyPool.StartHealthCheck(util.ProxyHealthCheckUrl, util.ProxyHealthCheckIntervalInS*time.Second)
+		defer params.ProxyPool.Stop()
+	}
+
 	// Create dependencies (TODO: adopt DI if # of dependencies exceeds 3)
-	bgmClient := dao.NewBgmApiAccessor()
+	bgmClient := dao.NewBgmApiAccessor(params.Transport())
 	konomiAccessor := dao.NewCRKonomiAccessor()
 	defer konomiAccessor.Disconnect()
+	vipEvaluator := helper.NewVipEvaluator(bgmClient, konomiAccessor, params.VipRuleSets, params.VipThresholds,
+		time.Duration(params.CandidateCooldownInDays)*24*time.Hour, params.SubjectFilter)
+	if params.SubjectFilter.NeedsSubjectPlatforms() {
+		subjectPlatforms, err := konomiAccessor.GetSubjectPlatforms()
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to read subject platforms")
+		}
+		params.SubjectFilter.SetSubjectPlatforms(subjectPlatforms)
+	}
+	defer params.SubjectFilter.LogDropCounts()
 
 	// Start execution
-	params := param.GetParams()
 	if params.Mode == param.ColdStartMode {
-		orch := orch.NewColdStartOrchestrator(bgmClient, konomiAccessor)
+		frontier := scraper.NewFrontier(params.ScraperCheckpointPath)
+		if params.Resume {
+			frontier, err = scraper.LoadFrontier(params.ScraperCheckpointPath)
+			if err != nil {
+				log.Fatal().Err(err).Msg("Failed to load scraper checkpoint")
+			}
+		}
+		orch := orch.NewColdStartOrchestrator(bgmClient, konomiAccessor, params.SyncedCollectionTypes, params.ScrapingPolicy,
+			frontier, params.Resume, params.HarvestScrapedCollections, params.Discovery, params.MaxCandidatesPerRun, vipEvaluator)
 		orch.Run(util.NumOfSubjectRetrievers, util.NumOfUserIdRetrievers, util.NumOfUserIdMergers, params.ColdStartIntervalInDays)
 	} else if params.Mode == param.RegularUpdateMode {
-		orch := orch.NewUpdateOrchestrator(bgmClient, konomiAccessor)
+		orch := orch.NewUpdateOrchestrator(bgmClient, konomiAccessor, params.SyncedCollectionTypes, vipEvaluator, params.InactiveStrikesBeforeRemoval)
 		orch.Run(util.NumOfUserIDReaders, util.NumOfUserUpdaters, util.NumOfUserCleaners)
+	} else if params.Mode == param.RelationSyncMode {
+		orch := orch.NewRelationSyncOrchestrator(bgmClient, konomiAccessor)
+		orch.Run(util.NumOfSubjectRelationSyncers, util.SubjectSyncBatchSize, util.RelationRefreshIntervalInDays)
+	} else if params.Mode == param.StaffSyncMode {
+		orch := orch.NewStaffSyncOrchestrator(bgmClient, konomiAccessor)
+		orch.Run(util.NumOfSubjectStaffSyncers, util.SubjectSyncBatchSize, util.StaffRefreshIntervalInDays)
+	} else if params.Mode == param.ArchiveImportMode {
+		orch := orch.NewArchiveImportOrchestrator(bgmClient, konomiAccessor)
+		orch.Run(params.ArchiveDumpPath)
+	} else if params.Mode == param.SnowballMode {
+		orch := orch.NewSnowballOrchestrator(bgmClient, konomiAccessor, params.SyncedCollectionTypes, params.ScrapingPolicy, vipEvaluator)
+		orch.Run(params.SnowballMaxDepth, params.SnowballMaxUids)
+	} else if params.Mode == param.SimulateMode {
+		orch := orch.NewVipSimulationOrchestrator(konomiAccessor)
+		orch.Run(params.Simulation.ThresholdSets, helper.RuleNames(params.VipRuleSets[model.Anime]), params.Simulation.AsOfDates)
+	} else if params.Mode == param.StatsMode {
+		orch := orch.NewTierStatsOrchestrator(konomiAccessor)
+		orch.Run()
+	} else if params.Mode == param.TimeZoneBackfillMode {
+		orch := orch.NewTimeZoneBackfillOrchestrator(konomiAccessor)
+		orch.Run()
 	} else {
 		log.Info().Msg("Not on a run date. Exiting...")
 		os.Exit(0)
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+
+	jetmodel "github.com/AlcEccentric/beck-mizuki/model/gen/beck-konomi/public/model"
+)
+
+// CandidateSighting records that a cold start candidate was found on a source
+// Source is the frontier key of the scraped subject page (e.g. "12" or "doings/12"), or the user list (e.g. "group/a")
+type CandidateSighting struct {
+	UserID    string
+	Source    string
+	SubjectID string // empty if the source is not a subject page
+	SightedAt time.Time
+}
+
+func (s *CandidateSighting) ToBgmCandidateSighting() jetmodel.BgmCandidateSighting {
+	bgmCandidateSighting := jetmodel.BgmCandidateSighting{
+		UserID:    s.UserID,
+		Source:    s.Source,
+		SightedAt: &s.SightedAt,
+	}
+	if s.SubjectID != "" {
+		bgmCandidateSighting.SubjectID = &s.SubjectID
+	}
+	return bgmCandidateSighting
+}
+
+func ToBgmCandidateSightings(sightings []CandidateSighting) []jetmodel.BgmCandidateSighting {
+	bgmCandidateSightings := make([]jetmodel.BgmCandidateSighting, 0, len(sightings))
+	for _, sighting := range sightings {
+		bgmCandidateSightings = append(bgmCandidateSightings, sighting.ToBgmCandidateSighting())
+	}
+	return bgmCandidateSightings
+}
+
+// Candidate aggregates the sightings of a user
+type Candidate struct {
+	UserID        string
+	SubjectCnt    int64 // number of subjects the user was seen on
+	LastSightedAt time.Time
+}
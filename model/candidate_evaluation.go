@@ -0,0 +1,53 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	jetmodel "github.com/AlcEccentric/beck-mizuki/model/gen/beck-konomi/public/model"
+)
+
+// CandidateEvaluation is the outcome of the last VIP evaluation of a cold start candidate
+type CandidateEvaluation struct {
+	UserID      string
+	SubjectType SubjectType
+	Passed      bool
+	FailedRule  string             // empty if passed
+	Measured    map[string]float64 // value measured by each evaluated rule
+	EvaluatedAt time.Time
+}
+
+func (e *CandidateEvaluation) ToBgmCandidate() (jetmodel.BgmCandidate, error) {
+	measured, err := json.Marshal(e.Measured)
+	if err != nil {
+		return jetmodel.BgmCandidate{}, err
+	}
+	subjectType := int64(e.SubjectType)
+	measuredStr := string(measured)
+	return jetmodel.BgmCandidate{
+		UserID:      e.UserID,
+		SubjectType: &subjectType,
+		Passed:      &e.Passed,
+		FailedRule:  &e.FailedRule,
+		Measured:    &measuredStr,
+		EvaluatedAt: &e.EvaluatedAt,
+	}, nil
+}
+
+func FromBgmCandidate(bgmCandidate jetmodel.BgmCandidate) (CandidateEvaluation, error) {
+	evaluation := CandidateEvaluation{
+		UserID:      bgmCandidate.UserID,
+		SubjectType: SubjectType(*bgmCandidate.SubjectType),
+		Passed:      *bgmCandidate.Passed,
+		EvaluatedAt: *bgmCandidate.EvaluatedAt,
+	}
+	if bgmCandidate.FailedRule != nil {
+		evaluation.FailedRule = *bgmCandidate.FailedRule
+	}
+	if bgmCandidate.Measured != nil {
+		if err := json.Unmarshal([]byte(*bgmCandidate.Measured), &evaluation.Measured); err != nil {
+			return CandidateEvaluation{}, err
+		}
+	}
+	return evaluation, nil
+}
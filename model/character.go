@@ -0,0 +1,60 @@
+package model
+
+import (
+	jetmodel "github.com/AlcEccentric/beck-mizuki/model/gen/beck-konomi/public/model"
+)
+
+type Character struct {
+	Id   string
+	Name string
+	Type int64 // 1: character, 2: mechanic, 3: ship, 4: organization
+}
+
+// SubjectCharacter links a character to a subject with the role (e.g. 主角, 配角) it plays in it
+type SubjectCharacter struct {
+	SubjectID   string
+	CharacterID string
+	Role        string
+}
+
+var characterRoleLabels = map[int64]string{
+	1: "主角",
+	2: "配角",
+	3: "客串",
+}
+
+func CharacterRoleLabel(roleType int64) string {
+	return characterRoleLabels[roleType]
+}
+
+func (c *Character) ToBgmCharacter() jetmodel.BgmCharacter {
+	return jetmodel.BgmCharacter{
+		ID:   c.Id,
+		Name: &c.Name,
+		Type: &c.Type,
+	}
+}
+
+func ToBgmCharacters(characters []Character) []jetmodel.BgmCharacter {
+	bgmCharacters := make([]jetmodel.BgmCharacter, 0, len(characters))
+	for _, character := range characters {
+		bgmCharacters = append(bgmCharacters, character.ToBgmCharacter())
+	}
+	return bgmCharacters
+}
+
+func (sc *SubjectCharacter) ToBgmSubjectCharacter() jetmodel.BgmSubjectCharacter {
+	return jetmodel.BgmSubjectCharacter{
+		SubjectID:   sc.SubjectID,
+		CharacterID: sc.CharacterID,
+		Role:        &sc.Role,
+	}
+}
+
+func ToBgmSubjectCharacters(subjectCharacters []SubjectCharacter) []jetmodel.BgmSubjectCharacter {
+	bgmSubjectCharacters := make([]jetmodel.BgmSubjectCharacter, 0, len(subjectCharacters))
+	for _, subjectCharacter := range subjectCharacters {
+		bgmSubjectCharacters = append(bgmSubjectCharacters, subjectCharacter.ToBgmSubjectCharacter())
+	}
+	return bgmSubjectCharacters
+}
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	jetmodel "github.com/AlcEccentric/beck-mizuki/model/gen/beck-konomi/public/model"
@@ -34,23 +36,38 @@ func (ct CollectionType) String() string {
 	}
 }
 
+// CollectionTypeFromString parses the name returned by CollectionType.String (case insensitive)
+func CollectionTypeFromString(ctypeStr string) (CollectionType, error) {
+	for ct := ToWatch; ct <= Discarded; ct++ {
+		if strings.EqualFold(ct.String(), ctypeStr) {
+			return ct, nil
+		}
+	}
+	return 0, fmt.Errorf("collection type %s is not supported", ctypeStr)
+}
+
 type Collection struct {
 	UserID         string    `bson:"user_id" gorm:"column:user_id"`
 	SubjectID      string    `bson:"subject_id" gorm:"column:subject_id"`
 	SubjectType    int64     `bson:"subject_type" gorm:"column:subject_type"`
 	CollectionType int64     `bson:"collection_type" gorm:"column:collection_type"`
-	CollectedTime  time.Time `bson:"collected_time" gorm:"column:collected_time"`
-	Rating         int64     `bson:"rating,omitempty" gorm:"column:rating"`
+	CollectedTime  time.Time `bson:"collected_time" gorm:"column:collected_time"` // in UTC
+	// zone name or offset the source gave the collected time in, e.g. Asia/Shanghai or +08:00
+	CollectedTimeZone string `bson:"collected_time_zone,omitempty" gorm:"column:collected_time_zone"`
+	Rating            int64  `bson:"rating,omitempty" gorm:"column:rating"`
+	// Detail is persisted separately into the collection detail table
+	Detail *CollectionDetail `bson:"-" gorm:"-"`
 }
 
 func (c *Collection) ToBgmUserCollection() jetmodel.BgmUserCollection {
 	return jetmodel.BgmUserCollection{
-		UserID:         c.UserID,
-		SubjectID:      c.SubjectID,
-		SubjectType:    &c.SubjectType,
-		CollectionType: &c.CollectionType,
-		CollectedTime:  &c.CollectedTime,
-		Rating:         &c.Rating,
+		UserID:            c.UserID,
+		SubjectID:         c.SubjectID,
+		SubjectType:       &c.SubjectType,
+		CollectionType:    &c.CollectionType,
+		CollectedTime:     &c.CollectedTime,
+		CollectedTimeZone: &c.CollectedTimeZone,
+		Rating:            &c.Rating,
 	}
 }
 
@@ -61,3 +78,27 @@ func ToBgmUserCollections(collections []Collection) []jetmodel.BgmUserCollection
 	}
 	return bgmUserCollections
 }
+
+func FromBgmUserCollection(bgmUserCollection jetmodel.BgmUserCollection) Collection {
+	collection := Collection{
+		UserID:         bgmUserCollection.UserID,
+		SubjectID:      bgmUserCollection.SubjectID,
+		SubjectType:    *bgmUserCollection.SubjectType,
+		CollectionType: *bgmUserCollection.CollectionType,
+		CollectedTime:  bgmUserCollection.CollectedTime.UTC(),
+		Rating:         *bgmUserCollection.Rating,
+	}
+	// collections stored before the zone was recorded have none
+	if bgmUserCollection.CollectedTimeZone != nil {
+		collection.CollectedTimeZone = *bgmUserCollection.CollectedTimeZone
+	}
+	return collection
+}
+
+func FromBgmUserCollections(bgmUserCollections []jetmodel.BgmUserCollection) []Collection {
+	collections := make([]Collection, 0, len(bgmUserCollections))
+	for _, bgmUserCollection := range bgmUserCollections {
+		collections = append(collections, FromBgmUserCollection(bgmUserCollection))
+	}
+	return collections
+}
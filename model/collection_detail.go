@@ -0,0 +1,64 @@
+package model
+
+import (
+	"encoding/json"
+
+	jetmodel "github.com/AlcEccentric/beck-mizuki/model/gen/beck-konomi/public/model"
+)
+
+// CollectionDetail holds the free text part of a collection
+// It is kept out of Collection so that the collection table stays small
+type CollectionDetail struct {
+	UserID    string   `bson:"user_id" gorm:"column:user_id"`
+	SubjectID string   `bson:"subject_id" gorm:"column:subject_id"`
+	Comment   string   `bson:"comment,omitempty" gorm:"column:comment"`
+	Tags      []string `bson:"tags,omitempty" gorm:"column:tags"`
+}
+
+func (d *CollectionDetail) IsEmpty() bool {
+	return d.Comment == "" && len(d.Tags) == 0
+}
+
+func (d *CollectionDetail) ToBgmUserCollectionDetail() jetmodel.BgmUserCollectionDetail {
+	// tags are stored as a json array
+	tags := "[]"
+	if tagsJson, err := json.Marshal(d.Tags); err == nil && d.Tags != nil {
+		tags = string(tagsJson)
+	}
+	return jetmodel.BgmUserCollectionDetail{
+		UserID:    d.UserID,
+		SubjectID: d.SubjectID,
+		Comment:   &d.Comment,
+		Tags:      &tags,
+	}
+}
+
+func ToBgmUserCollectionDetails(details []CollectionDetail) []jetmodel.BgmUserCollectionDetail {
+	bgmUserCollectionDetails := make([]jetmodel.BgmUserCollectionDetail, 0, len(details))
+	for _, detail := range details {
+		bgmUserCollectionDetails = append(bgmUserCollectionDetails, detail.ToBgmUserCollectionDetail())
+	}
+	return bgmUserCollectionDetails
+}
+
+// DetailsOf extracts the non-empty details attached to the collections
+func DetailsOf(collections []Collection) []CollectionDetail {
+	details := make([]CollectionDetail, 0)
+	for _, collection := range collections {
+		if collection.Detail != nil && !collection.Detail.IsEmpty() {
+			details = append(details, *collection.Detail)
+		}
+	}
+	return details
+}
+
+// EmptyDetailsOf extracts the empty details attached to the collections, whose stored detail was cleared if any
+func EmptyDetailsOf(collections []Collection) []CollectionDetail {
+	details := make([]CollectionDetail, 0)
+	for _, collection := range collections {
+		if collection.Detail != nil && collection.Detail.IsEmpty() {
+			details = append(details, *collection.Detail)
+		}
+	}
+	return details
+}
@@ -12,8 +12,17 @@ import (
 )
 
 type BgmUser struct {
-	ID             string `sql:"primary_key"`
-	Nickname       *string
-	AvatarURL      *string
-	LastActiveTime *time.Time
+	ID                   string `sql:"primary_key"`
+	Nickname             *string
+	AvatarURL            *string
+	LastActiveTime       *time.Time
+	QualityScore         *float64
+	Status               *string
+	ConsecutiveFailures  *int64
+	BotFlag              *string
+	Tier                 *int64
+	RawWatchedCount      *int64
+	FilteredWatchedCount *int64
+	LastEvaluatedAt      *time.Time
+	ActivityGap          *float64
 }
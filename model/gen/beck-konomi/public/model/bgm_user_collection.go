@@ -12,10 +12,11 @@ import (
 )
 
 type BgmUserCollection struct {
-	UserID         string `sql:"primary_key"`
-	SubjectID      string `sql:"primary_key"`
-	SubjectType    *int64
-	CollectionType *int64
-	CollectedTime  *time.Time
-	Rating         *int64
+	UserID            string `sql:"primary_key"`
+	SubjectID         string `sql:"primary_key"`
+	SubjectType       *int64
+	CollectionType    *int64
+	CollectedTime     *time.Time
+	CollectedTimeZone *string
+	Rating            *int64
 }
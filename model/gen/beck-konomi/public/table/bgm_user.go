@@ -17,10 +17,19 @@ type bgmUserTable struct {
 	postgres.Table
 
 	// Columns
-	ID             postgres.ColumnString
-	Nickname       postgres.ColumnString
-	AvatarURL      postgres.ColumnString
-	LastActiveTime postgres.ColumnTimestampz
+	ID                   postgres.ColumnString
+	Nickname             postgres.ColumnString
+	AvatarURL            postgres.ColumnString
+	LastActiveTime       postgres.ColumnTimestampz
+	QualityScore         postgres.ColumnFloat
+	Status               postgres.ColumnString
+	ConsecutiveFailures  postgres.ColumnInteger
+	BotFlag              postgres.ColumnString
+	Tier                 postgres.ColumnInteger
+	RawWatchedCount      postgres.ColumnInteger
+	FilteredWatchedCount postgres.ColumnInteger
+	LastEvaluatedAt      postgres.ColumnTimestampz
+	ActivityGap          postgres.ColumnFloat
 
 	AllColumns     postgres.ColumnList
 	MutableColumns postgres.ColumnList
@@ -61,22 +70,40 @@ func newBgmUserTable(schemaName, tableName, alias string) *BgmUserTable {
 
 func newBgmUserTableImpl(schemaName, tableName, alias string) bgmUserTable {
 	var (
-		IDColumn             = postgres.StringColumn("id")
-		NicknameColumn       = postgres.StringColumn("nickname")
-		AvatarURLColumn      = postgres.StringColumn("avatar_url")
-		LastActiveTimeColumn = postgres.TimestampzColumn("last_active_time")
-		allColumns           = postgres.ColumnList{IDColumn, NicknameColumn, AvatarURLColumn, LastActiveTimeColumn}
-		mutableColumns       = postgres.ColumnList{NicknameColumn, AvatarURLColumn, LastActiveTimeColumn}
+		IDColumn                   = postgres.StringColumn("id")
+		NicknameColumn             = postgres.StringColumn("nickname")
+		AvatarURLColumn            = postgres.StringColumn("avatar_url")
+		LastActiveTimeColumn       = postgres.TimestampzColumn("last_active_time")
+		QualityScoreColumn         = postgres.FloatColumn("quality_score")
+		StatusColumn               = postgres.StringColumn("status")
+		ConsecutiveFailuresColumn  = postgres.IntegerColumn("consecutive_failures")
+		BotFlagColumn              = postgres.StringColumn("bot_flag")
+		TierColumn                 = postgres.IntegerColumn("tier")
+		RawWatchedCountColumn      = postgres.IntegerColumn("raw_watched_count")
+		FilteredWatchedCountColumn = postgres.IntegerColumn("filtered_watched_count")
+		LastEvaluatedAtColumn      = postgres.TimestampzColumn("last_evaluated_at")
+		ActivityGapColumn          = postgres.FloatColumn("activity_gap")
+		allColumns                 = postgres.ColumnList{IDColumn, NicknameColumn, AvatarURLColumn, LastActiveTimeColumn, QualityScoreColumn, StatusColumn, ConsecutiveFailuresColumn, BotFlagColumn, TierColumn, RawWatchedCountColumn, FilteredWatchedCountColumn, LastEvaluatedAtColumn, ActivityGapColumn}
+		mutableColumns             = postgres.ColumnList{NicknameColumn, AvatarURLColumn, LastActiveTimeColumn, QualityScoreColumn, StatusColumn, ConsecutiveFailuresColumn, BotFlagColumn, TierColumn, RawWatchedCountColumn, FilteredWatchedCountColumn, LastEvaluatedAtColumn, ActivityGapColumn}
 	)
 
 	return bgmUserTable{
 		Table: postgres.NewTable(schemaName, tableName, alias, allColumns...),
 
 		//Columns
-		ID:             IDColumn,
-		Nickname:       NicknameColumn,
-		AvatarURL:      AvatarURLColumn,
-		LastActiveTime: LastActiveTimeColumn,
+		ID:                   IDColumn,
+		Nickname:             NicknameColumn,
+		AvatarURL:            AvatarURLColumn,
+		LastActiveTime:       LastActiveTimeColumn,
+		QualityScore:         QualityScoreColumn,
+		Status:               StatusColumn,
+		ConsecutiveFailures:  ConsecutiveFailuresColumn,
+		BotFlag:              BotFlagColumn,
+		Tier:                 TierColumn,
+		RawWatchedCount:      RawWatchedCountColumn,
+		FilteredWatchedCount: FilteredWatchedCountColumn,
+		LastEvaluatedAt:      LastEvaluatedAtColumn,
+		ActivityGap:          ActivityGapColumn,
 
 		AllColumns:     allColumns,
 		MutableColumns: mutableColumns,
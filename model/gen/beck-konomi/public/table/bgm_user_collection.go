@@ -17,12 +17,13 @@ type bgmUserCollectionTable struct {
 	postgres.Table
 
 	// Columns
-	UserID         postgres.ColumnString
-	SubjectID      postgres.ColumnString
-	SubjectType    postgres.ColumnInteger
-	CollectionType postgres.ColumnInteger
-	CollectedTime  postgres.ColumnTimestampz
-	Rating         postgres.ColumnInteger
+	UserID            postgres.ColumnString
+	SubjectID         postgres.ColumnString
+	SubjectType       postgres.ColumnInteger
+	CollectionType    postgres.ColumnInteger
+	CollectedTime     postgres.ColumnTimestampz
+	CollectedTimeZone postgres.ColumnString
+	Rating            postgres.ColumnInteger
 
 	AllColumns     postgres.ColumnList
 	MutableColumns postgres.ColumnList
@@ -63,26 +64,28 @@ func newBgmUserCollectionTable(schemaName, tableName, alias string) *BgmUserColl
 
 func newBgmUserCollectionTableImpl(schemaName, tableName, alias string) bgmUserCollectionTable {
 	var (
-		UserIDColumn         = postgres.StringColumn("user_id")
-		SubjectIDColumn      = postgres.StringColumn("subject_id")
-		SubjectTypeColumn    = postgres.IntegerColumn("subject_type")
-		CollectionTypeColumn = postgres.IntegerColumn("collection_type")
-		CollectedTimeColumn  = postgres.TimestampzColumn("collected_time")
-		RatingColumn         = postgres.IntegerColumn("rating")
-		allColumns           = postgres.ColumnList{UserIDColumn, SubjectIDColumn, SubjectTypeColumn, CollectionTypeColumn, CollectedTimeColumn, RatingColumn}
-		mutableColumns       = postgres.ColumnList{SubjectTypeColumn, CollectionTypeColumn, CollectedTimeColumn, RatingColumn}
+		UserIDColumn            = postgres.StringColumn("user_id")
+		SubjectIDColumn         = postgres.StringColumn("subject_id")
+		SubjectTypeColumn       = postgres.IntegerColumn("subject_type")
+		CollectionTypeColumn    = postgres.IntegerColumn("collection_type")
+		CollectedTimeColumn     = postgres.TimestampzColumn("collected_time")
+		CollectedTimeZoneColumn = postgres.StringColumn("collected_time_zone")
+		RatingColumn            = postgres.IntegerColumn("rating")
+		allColumns              = postgres.ColumnList{UserIDColumn, SubjectIDColumn, SubjectTypeColumn, CollectionTypeColumn, CollectedTimeColumn, CollectedTimeZoneColumn, RatingColumn}
+		mutableColumns          = postgres.ColumnList{SubjectTypeColumn, CollectionTypeColumn, CollectedTimeColumn, CollectedTimeZoneColumn, RatingColumn}
 	)
 
 	return bgmUserCollectionTable{
 		Table: postgres.NewTable(schemaName, tableName, alias, allColumns...),
 
 		//Columns
-		UserID:         UserIDColumn,
-		SubjectID:      SubjectIDColumn,
-		SubjectType:    SubjectTypeColumn,
-		CollectionType: CollectionTypeColumn,
-		CollectedTime:  CollectedTimeColumn,
-		Rating:         RatingColumn,
+		UserID:            UserIDColumn,
+		SubjectID:         SubjectIDColumn,
+		SubjectType:       SubjectTypeColumn,
+		CollectionType:    CollectionTypeColumn,
+		CollectedTime:     CollectedTimeColumn,
+		CollectedTimeZone: CollectedTimeZoneColumn,
+		Rating:            RatingColumn,
 
 		AllColumns:     allColumns,
 		MutableColumns: mutableColumns,
@@ -10,6 +10,19 @@ package table
 // UseSchema sets a new schema name for all generated table SQL builder types. It is recommended to invoke
 // this method only once at the beginning of the program.
 func UseSchema(schema string) {
+	BgmCandidate = BgmCandidate.FromSchema(schema)
+	BgmCandidateSighting = BgmCandidateSighting.FromSchema(schema)
+	BgmCharacter = BgmCharacter.FromSchema(schema)
+	BgmPerson = BgmPerson.FromSchema(schema)
+	BgmScrapeWatermark = BgmScrapeWatermark.FromSchema(schema)
+	BgmScrapedCollection = BgmScrapedCollection.FromSchema(schema)
+	BgmSubject = BgmSubject.FromSchema(schema)
+	BgmSubjectCharacter = BgmSubjectCharacter.FromSchema(schema)
+	BgmSubjectPerson = BgmSubjectPerson.FromSchema(schema)
+	BgmSubjectRelation = BgmSubjectRelation.FromSchema(schema)
+	BgmTierStats = BgmTierStats.FromSchema(schema)
 	BgmUser = BgmUser.FromSchema(schema)
 	BgmUserCollection = BgmUserCollection.FromSchema(schema)
+	BgmUserCollectionDetail = BgmUserCollectionDetail.FromSchema(schema)
+	BgmUserFriend = BgmUserFriend.FromSchema(schema)
 }
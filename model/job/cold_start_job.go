@@ -5,6 +5,6 @@ import (
 )
 
 type ColdStartOrchJob struct {
-	Subjects []model.Subject
-	UserIds  []string
+	Subjects  []model.Subject
+	Sightings []model.CandidateSighting
 }
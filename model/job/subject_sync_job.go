@@ -0,0 +1,5 @@
+package job
+
+type SubjectSyncOrchJob struct {
+	SubjectIds []string
+}
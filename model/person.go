@@ -0,0 +1,156 @@
+package model
+
+import (
+	"encoding/json"
+
+	jetmodel "github.com/AlcEccentric/beck-mizuki/model/gen/beck-konomi/public/model"
+)
+
+type Person struct {
+	Id     string
+	Name   string
+	Type   int64 // 1: individual, 2: company, 3: group
+	Career []string
+}
+
+// SubjectPerson links a person to a subject with the role (e.g. 导演, 动画制作, 原作) the person took in it
+type SubjectPerson struct {
+	SubjectID string
+	PersonID  string
+	Role      string
+	Position  int64 // bangumi staff position code, 0 when unknown
+}
+
+// bangumi staff positions of anime subjects, labelled with the relation the api returns for them so that both sources upsert the same rows
+// StaffPositionLabel returns an empty label for positions missing here
+var staffPositionLabels = map[int64]string{
+	1:  "原作",
+	2:  "导演",
+	3:  "脚本",
+	4:  "分镜",
+	5:  "演出",
+	6:  "音乐",
+	7:  "人物原案",
+	8:  "人物设定",
+	9:  "构图",
+	10: "系列构成",
+	11: "美术监督",
+	13: "色彩设计",
+	14: "总作画监督",
+	15: "作画监督",
+	16: "机械设定",
+	17: "摄影监督",
+	18: "监修",
+	19: "道具设计",
+	20: "原画",
+	21: "第二原画",
+	22: "动画检查",
+	23: "助理制片人",
+	24: "制作助理",
+	25: "背景美术",
+	26: "色彩指定",
+	27: "数码绘图",
+	28: "剪辑",
+	29: "原案",
+	30: "主题歌编曲",
+	31: "主题歌作曲",
+	32: "主题歌作词",
+	33: "主题歌演出",
+	34: "插入歌演出",
+	35: "企画",
+	36: "企划制作人",
+	37: "制作管理",
+	38: "宣传",
+	39: "录音",
+	40: "录音助理",
+	41: "系列监督",
+	42: "製作",
+	43: "设定",
+	44: "音响监督",
+	45: "音响",
+	46: "音效",
+	47: "特效",
+	48: "配音监督",
+	49: "联合导演",
+	50: "背景设定",
+	51: "补间动画",
+	52: "执行制片人",
+	54: "制片人",
+	55: "音乐助理",
+	56: "制作进行",
+	57: "演员监督",
+	58: "总制片人",
+	59: "联合制片人",
+	60: "台词编辑",
+	61: "后期制片协调",
+	63: "制作",
+	64: "制作协调",
+	65: "音乐制作",
+	66: "特别鸣谢",
+	67: "动画制作",
+	69: "CG 导演",
+	70: "机械作画监督",
+	71: "美术设计",
+	72: "副导演",
+	73: "OP・ED 分镜",
+	74: "总导演",
+	75: "3DCG",
+	76: "制作协力",
+	77: "动作作画监督",
+	80: "监制",
+	81: "协力",
+	82: "摄影",
+	83: "制作进行协力",
+	84: "设定制作",
+	85: "音乐制作人",
+	86: "3DCG 导演",
+	87: "动画制片人",
+	88: "特效作画监督",
+	89: "主演出",
+	90: "作画监督助理",
+	91: "演出助理",
+	92: "主动画师",
+}
+
+func StaffPositionLabel(position int64) string {
+	return staffPositionLabels[position]
+}
+
+func (p *Person) ToBgmPerson() jetmodel.BgmPerson {
+	// career is stored as a json array
+	career := "[]"
+	if careerJson, err := json.Marshal(p.Career); err == nil && p.Career != nil {
+		career = string(careerJson)
+	}
+	return jetmodel.BgmPerson{
+		ID:     p.Id,
+		Name:   &p.Name,
+		Type:   &p.Type,
+		Career: &career,
+	}
+}
+
+func ToBgmPersons(persons []Person) []jetmodel.BgmPerson {
+	bgmPersons := make([]jetmodel.BgmPerson, 0, len(persons))
+	for _, person := range persons {
+		bgmPersons = append(bgmPersons, person.ToBgmPerson())
+	}
+	return bgmPersons
+}
+
+func (sp *SubjectPerson) ToBgmSubjectPerson() jetmodel.BgmSubjectPerson {
+	return jetmodel.BgmSubjectPerson{
+		SubjectID: sp.SubjectID,
+		PersonID:  sp.PersonID,
+		Role:      sp.Role,
+		Position:  &sp.Position,
+	}
+}
+
+func ToBgmSubjectPersons(subjectPersons []SubjectPerson) []jetmodel.BgmSubjectPerson {
+	bgmSubjectPersons := make([]jetmodel.BgmSubjectPerson, 0, len(subjectPersons))
+	for _, subjectPerson := range subjectPersons {
+		bgmSubjectPersons = append(bgmSubjectPersons, subjectPerson.ToBgmSubjectPerson())
+	}
+	return bgmSubjectPersons
+}
@@ -0,0 +1,9 @@
+package request
+
+type GetSubjectRelationsRequest struct {
+	Sid string
+}
+
+func (request *GetSubjectRelationsRequest) ToUri() string {
+	return "/v0/subjects/" + request.Sid + "/subjects"
+}
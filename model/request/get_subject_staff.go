@@ -0,0 +1,17 @@
+package request
+
+type GetSubjectPersonsRequest struct {
+	Sid string
+}
+
+func (request *GetSubjectPersonsRequest) ToUri() string {
+	return "/v0/subjects/" + request.Sid + "/persons"
+}
+
+type GetSubjectCharactersRequest struct {
+	Sid string
+}
+
+func (request *GetSubjectCharactersRequest) ToUri() string {
+	return "/v0/subjects/" + request.Sid + "/characters"
+}
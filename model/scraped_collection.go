@@ -0,0 +1,72 @@
+package model
+
+import (
+	"time"
+
+	jetmodel "github.com/AlcEccentric/beck-mizuki/model/gen/beck-konomi/public/model"
+)
+
+// ScrapedCollection is a collection read from the collections page of a subject on the website
+// It is staged apart from Collection as it is available for any user, not only VIPs
+type ScrapedCollection struct {
+	UserID            string
+	SubjectID         string
+	CollectionType    CollectionType // 0 when the status is not shown
+	CollectedTime     time.Time      // in UTC
+	CollectedTimeZone string         // zone the website showed the time in
+	Rating            int64          // 0 when not rated
+	Comment           string
+	ScrapedAt         time.Time
+}
+
+func (c *ScrapedCollection) ToBgmScrapedCollection() jetmodel.BgmScrapedCollection {
+	collectionType := int64(c.CollectionType)
+	return jetmodel.BgmScrapedCollection{
+		UserID:            c.UserID,
+		SubjectID:         c.SubjectID,
+		CollectionType:    &collectionType,
+		CollectedTime:     &c.CollectedTime,
+		CollectedTimeZone: &c.CollectedTimeZone,
+		Rating:            &c.Rating,
+		Comment:           &c.Comment,
+		ScrapedAt:         &c.ScrapedAt,
+	}
+}
+
+func ToBgmScrapedCollections(collections []ScrapedCollection) []jetmodel.BgmScrapedCollection {
+	bgmScrapedCollections := make([]jetmodel.BgmScrapedCollection, 0, len(collections))
+	for _, collection := range collections {
+		bgmScrapedCollections = append(bgmScrapedCollections, collection.ToBgmScrapedCollection())
+	}
+	return bgmScrapedCollections
+}
+
+func FromBgmScrapedCollection(bgmScrapedCollection jetmodel.BgmScrapedCollection) ScrapedCollection {
+	scrapedCollection := ScrapedCollection{
+		UserID:         bgmScrapedCollection.UserID,
+		SubjectID:      bgmScrapedCollection.SubjectID,
+		CollectionType: CollectionType(*bgmScrapedCollection.CollectionType),
+		CollectedTime:  bgmScrapedCollection.CollectedTime.UTC(),
+		Rating:         *bgmScrapedCollection.Rating,
+		Comment:        *bgmScrapedCollection.Comment,
+		ScrapedAt:      *bgmScrapedCollection.ScrapedAt,
+	}
+	// collections scraped before the zone was recorded have none
+	if bgmScrapedCollection.CollectedTimeZone != nil {
+		scrapedCollection.CollectedTimeZone = *bgmScrapedCollection.CollectedTimeZone
+	}
+	return scrapedCollection
+}
+
+// ToCollection converts the record of an anime subject page into a collection, scraped collections are always anime
+func (c *ScrapedCollection) ToCollection() Collection {
+	return Collection{
+		UserID:            c.UserID,
+		SubjectID:         c.SubjectID,
+		SubjectType:       int64(Anime),
+		CollectionType:    int64(c.CollectionType),
+		CollectedTime:     c.CollectedTime,
+		CollectedTimeZone: c.CollectedTimeZone,
+		Rating:            c.Rating,
+	}
+}
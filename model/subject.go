@@ -1,5 +1,12 @@
 package model
 
+import (
+	"fmt"
+	"strings"
+
+	jetmodel "github.com/AlcEccentric/beck-mizuki/model/gen/beck-konomi/public/model"
+)
+
 type SubjectType int
 
 // Define constants using iota
@@ -12,10 +19,15 @@ const (
 )
 
 type Subject struct {
-	Id        string
-	Type      SubjectType
-	Name      string
-	AvgRating float32
+	Id              string
+	Type            SubjectType
+	Name            string
+	NameCn          string
+	AvgRating       float32
+	Platform        int64  // bangumi platform code, e.g. TV, OVA, movie for anime
+	AirDate         string // in SubjectDateFormat, empty when unknown
+	Rank            int64
+	CollectionTotal int64
 }
 
 func (st SubjectType) String() string {
@@ -30,3 +42,36 @@ func (st SubjectType) String() string {
 		return "Unknown"
 	}
 }
+
+func SubjectTypeFromString(subjectTypeStr string) (SubjectType, error) {
+	for _, st := range []SubjectType{Manga, Anime, Game} {
+		if strings.EqualFold(st.String(), subjectTypeStr) {
+			return st, nil
+		}
+	}
+	return 0, fmt.Errorf("subject type %s is not supported", subjectTypeStr)
+}
+
+func (s *Subject) ToBgmSubject() jetmodel.BgmSubject {
+	subjectType := int64(s.Type)
+	score := float64(s.AvgRating)
+	return jetmodel.BgmSubject{
+		ID:              s.Id,
+		Type:            &subjectType,
+		Name:            &s.Name,
+		NameCn:          &s.NameCn,
+		Platform:        &s.Platform,
+		AirDate:         &s.AirDate,
+		Score:           &score,
+		Rank:            &s.Rank,
+		CollectionTotal: &s.CollectionTotal,
+	}
+}
+
+func ToBgmSubjects(subjects []Subject) []jetmodel.BgmSubject {
+	bgmSubjects := make([]jetmodel.BgmSubject, 0, len(subjects))
+	for _, subject := range subjects {
+		bgmSubjects = append(bgmSubjects, subject.ToBgmSubject())
+	}
+	return bgmSubjects
+}
@@ -0,0 +1,146 @@
+package model
+
+import (
+	jetmodel "github.com/AlcEccentric/beck-mizuki/model/gen/beck-konomi/public/model"
+)
+
+// SubjectRelationType follows the relation codes used by bangumi for anime subjects
+type SubjectRelationType int
+
+const (
+	Adaptation          SubjectRelationType = 1
+	Prequel             SubjectRelationType = 2
+	Sequel              SubjectRelationType = 3
+	Summary             SubjectRelationType = 4
+	FullStory           SubjectRelationType = 5
+	SideStory           SubjectRelationType = 6
+	CharacterAppearance SubjectRelationType = 7
+	SameSetting         SubjectRelationType = 8
+	AlternativeSetting  SubjectRelationType = 9
+	AlternativeVersion  SubjectRelationType = 10
+	SpinOff             SubjectRelationType = 11
+	ParentStory         SubjectRelationType = 12
+	OtherRelation       SubjectRelationType = 99
+)
+
+var subjectRelationLabels = map[SubjectRelationType]string{
+	Adaptation:          "改编",
+	Prequel:             "前传",
+	Sequel:              "续集",
+	Summary:             "总集篇",
+	FullStory:           "全集",
+	SideStory:           "番外篇",
+	CharacterAppearance: "角色出演",
+	SameSetting:         "相同世界观",
+	AlternativeSetting:  "不同世界观",
+	AlternativeVersion:  "不同演绎",
+	SpinOff:             "衍生",
+	ParentStory:         "主线故事",
+	OtherRelation:       "其他",
+}
+
+// SubjectRelationTypeFromLabel maps the relation label returned by the API to its code
+// Labels of non anime relations (e.g. between books) are not listed and fall back to OtherRelation
+func SubjectRelationTypeFromLabel(label string) SubjectRelationType {
+	for relationType, relationLabel := range subjectRelationLabels {
+		if relationLabel == label {
+			return relationType
+		}
+	}
+	return OtherRelation
+}
+
+// Label returns the relation label used by bangumi, or an empty string for codes not listed
+func (rt SubjectRelationType) Label() string {
+	return subjectRelationLabels[rt]
+}
+
+func (rt SubjectRelationType) String() string {
+	switch rt {
+	case Adaptation:
+		return "Adaptation"
+	case Prequel:
+		return "Prequel"
+	case Sequel:
+		return "Sequel"
+	case Summary:
+		return "Summary"
+	case FullStory:
+		return "FullStory"
+	case SideStory:
+		return "SideStory"
+	case CharacterAppearance:
+		return "CharacterAppearance"
+	case SameSetting:
+		return "SameSetting"
+	case AlternativeSetting:
+		return "AlternativeSetting"
+	case AlternativeVersion:
+		return "AlternativeVersion"
+	case SpinOff:
+		return "SpinOff"
+	case ParentStory:
+		return "ParentStory"
+	default:
+		return "Other"
+	}
+}
+
+// IsFranchise tells if the two related subjects should be considered as works of the same franchise
+// Adaptations are excluded as they link subjects of different types (e.g. manga and anime),
+// character appearances and alternative settings are excluded as they usually link independent stories
+func (rt SubjectRelationType) IsFranchise() bool {
+	switch rt {
+	case Prequel, Sequel, Summary, FullStory, SideStory, SameSetting, AlternativeVersion, SpinOff, ParentStory:
+		return true
+	default:
+		return false
+	}
+}
+
+type SubjectRelation struct {
+	SubjectID        string
+	RelatedSubjectID string
+	RelationType     SubjectRelationType
+	Relation         string // raw relation label
+}
+
+func (r *SubjectRelation) ToBgmSubjectRelation() jetmodel.BgmSubjectRelation {
+	relationType := int64(r.RelationType)
+	return jetmodel.BgmSubjectRelation{
+		SubjectID:        r.SubjectID,
+		RelatedSubjectID: r.RelatedSubjectID,
+		RelationType:     &relationType,
+		Relation:         &r.Relation,
+	}
+}
+
+func ToBgmSubjectRelations(relations []SubjectRelation) []jetmodel.BgmSubjectRelation {
+	bgmSubjectRelations := make([]jetmodel.BgmSubjectRelation, 0, len(relations))
+	for _, relation := range relations {
+		bgmSubjectRelations = append(bgmSubjectRelations, relation.ToBgmSubjectRelation())
+	}
+	return bgmSubjectRelations
+}
+
+func FromBgmSubjectRelation(bgmSubjectRelation jetmodel.BgmSubjectRelation) SubjectRelation {
+	relation := SubjectRelation{
+		SubjectID:        bgmSubjectRelation.SubjectID,
+		RelatedSubjectID: bgmSubjectRelation.RelatedSubjectID,
+	}
+	if bgmSubjectRelation.RelationType != nil {
+		relation.RelationType = SubjectRelationType(*bgmSubjectRelation.RelationType)
+	}
+	if bgmSubjectRelation.Relation != nil {
+		relation.Relation = *bgmSubjectRelation.Relation
+	}
+	return relation
+}
+
+func FromBgmSubjectRelations(bgmSubjectRelations []jetmodel.BgmSubjectRelation) []SubjectRelation {
+	relations := make([]SubjectRelation, 0, len(bgmSubjectRelations))
+	for _, bgmSubjectRelation := range bgmSubjectRelations {
+		relations = append(relations, FromBgmSubjectRelation(bgmSubjectRelation))
+	}
+	return relations
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	jetmodel "github.com/AlcEccentric/beck-mizuki/model/gen/beck-konomi/public/model"
+)
+
+// TierStat is the number of active users of a tier at a stats run
+type TierStat struct {
+	RunAt   time.Time
+	Tier    int
+	UserCnt int
+}
+
+func (s *TierStat) ToBgmTierStats() jetmodel.BgmTierStats {
+	tier := int64(s.Tier)
+	userCnt := int64(s.UserCnt)
+	return jetmodel.BgmTierStats{
+		RunAt:   s.RunAt,
+		Tier:    tier,
+		UserCnt: &userCnt,
+	}
+}
+
+func ToBgmTierStats(stats []TierStat) []jetmodel.BgmTierStats {
+	bgmTierStats := make([]jetmodel.BgmTierStats, 0, len(stats))
+	for _, stat := range stats {
+		bgmTierStats = append(bgmTierStats, stat.ToBgmTierStats())
+	}
+	return bgmTierStats
+}
@@ -6,6 +6,28 @@ import (
 	jetmodel "github.com/AlcEccentric/beck-mizuki/model/gen/beck-konomi/public/model"
 )
 
+// UserStatus tells if the data of a user is exported, users failing the activity check are inactive until they are removed or active again
+// No exporter lives in this repo yet, readers feeding exports must only read active users, see dao.KonomiAccessor.GetActiveUserIds
+type UserStatus string
+
+const (
+	ActiveUser   UserStatus = "active"
+	InactiveUser UserStatus = "inactive"
+)
+
+// UnevaluatedTier is the tier of users persisted before tiers were recorded
+const UnevaluatedTier = -1
+
+// UserEvaluation is the activity of a user measured at the last evaluation
+type UserEvaluation struct {
+	UserID               string
+	Tier                 int // 0 under T1WatchedCnt, then 1 to 3, see helper.VipThresholds.Tier
+	RawWatchedCount      int
+	FilteredWatchedCount int
+	ActivityGap          float64 // measured by the activity model, e.g. intervals without a watched collection for the bucket model
+	EvaluatedAt          time.Time
+}
+
 type User struct {
 	ID             string    `bson:"_id" gorm:"primaryKey;column:id"`
 	Nickname       string    `bson:"nickname,omitempty" gorm:"column:nickname"`
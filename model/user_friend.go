@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+
+	jetmodel "github.com/AlcEccentric/beck-mizuki/model/gen/beck-konomi/public/model"
+)
+
+// UserFriend is a directed edge of the friend graph, from a user to someone on their friend list
+type UserFriend struct {
+	UserID       string
+	FriendID     string
+	DiscoveredAt time.Time
+}
+
+func (f *UserFriend) ToBgmUserFriend() jetmodel.BgmUserFriend {
+	return jetmodel.BgmUserFriend{
+		UserID:       f.UserID,
+		FriendID:     f.FriendID,
+		DiscoveredAt: &f.DiscoveredAt,
+	}
+}
+
+func ToBgmUserFriends(friends []UserFriend) []jetmodel.BgmUserFriend {
+	bgmUserFriends := make([]jetmodel.BgmUserFriend, 0, len(friends))
+	for _, friend := range friends {
+		bgmUserFriends = append(bgmUserFriends, friend.ToBgmUserFriend())
+	}
+	return bgmUserFriends
+}
@@ -0,0 +1,40 @@
+package orch
+
+import (
+	dao "github.com/AlcEccentric/beck-mizuki/dao"
+	"github.com/AlcEccentric/beck-mizuki/service"
+	"github.com/rs/zerolog/log"
+)
+
+type ArchiveImportOrchestrator struct {
+	archiveImportSvc   *service.ArchiveImportService
+	subjectRelationSvc *service.SubjectRelationService
+}
+
+func NewArchiveImportOrchestrator(bgmClient *dao.BgmApiAccessor, konomiAccessor dao.KonomiAccessor) *ArchiveImportOrchestrator {
+	return &ArchiveImportOrchestrator{
+		archiveImportSvc:   service.NewArchiveImportService(konomiAccessor),
+		subjectRelationSvc: service.NewSubjectRelationService(bgmClient, konomiAccessor),
+	}
+}
+
+func (orch *ArchiveImportOrchestrator) Run(dumpPath string) {
+	log.Info().
+		Str("dumpPath", dumpPath).
+		Msg("Start archive import orchestrator")
+
+	if dumpPath == "" {
+		log.Fatal().Msg("ARCHIVE_DUMP_PATH environment variable is not set")
+	}
+
+	// 1. import subjects, persons, characters and their relations from the dump
+	// 2. recompute franchise ids as the dump usually brings many new relations
+	if err := orch.archiveImportSvc.Import(dumpPath); err != nil {
+		log.Error().Err(err).Msg("Failed to import archive dump")
+		return
+	}
+
+	if err := orch.subjectRelationSvc.UpdateFranchises(); err != nil {
+		log.Error().Err(err).Msg("Failed to update franchise ids")
+	}
+}
@@ -5,22 +5,41 @@ import (
 	"github.com/rs/zerolog/log"
 
 	dao "github.com/AlcEccentric/beck-mizuki/dao"
+	"github.com/AlcEccentric/beck-mizuki/helper"
+	model "github.com/AlcEccentric/beck-mizuki/model"
+	"github.com/AlcEccentric/beck-mizuki/param"
+	"github.com/AlcEccentric/beck-mizuki/scraper"
 	"github.com/AlcEccentric/beck-mizuki/service"
+	"github.com/AlcEccentric/beck-mizuki/util"
 )
 
 type ColdStartOrchestrator struct {
 	bgmClient          *dao.BgmApiAccessor
+	policy             *scraper.ScrapingPolicy
+	frontier           *scraper.Frontier
+	resume             bool
+	maxCandidates      int // 0 to evaluate all candidates, the others are discarded with the candidate store
+	discoveryParams    param.DiscoveryParams
 	subjectSvc         *service.SubjectService
+	candidateStore     *service.CandidateStore
 	userIdSvc          *service.UserIdScrapingService
 	persistenceService *service.UserPersistingService
 }
 
-func NewColdStartOrchestrator(bgmClient *dao.BgmApiAccessor, konomiAccessor dao.KonomiAccessor) *ColdStartOrchestrator {
+func NewColdStartOrchestrator(bgmClient *dao.BgmApiAccessor, konomiAccessor dao.KonomiAccessor, syncedCollectionTypes []model.CollectionType,
+	policy *scraper.ScrapingPolicy, frontier *scraper.Frontier, resume bool, harvestScrapedCollections bool, discoveryParams param.DiscoveryParams,
+	maxCandidates int, vipEvaluator *helper.VipEvaluator) *ColdStartOrchestrator {
 	return &ColdStartOrchestrator{
 		bgmClient:          bgmClient,
+		policy:             policy,
+		frontier:           frontier,
+		resume:             resume,
+		maxCandidates:      maxCandidates,
+		discoveryParams:    discoveryParams,
 		subjectSvc:         service.NewSubjectService(bgmClient),
-		userIdSvc:          service.NewUserIdScrapingService(),
-		persistenceService: service.NewUserPersistenceService(bgmClient, konomiAccessor),
+		candidateStore:     service.NewCandidateStore(konomiAccessor),
+		userIdSvc:          service.NewUserIdScrapingService(konomiAccessor, policy, frontier, getSubjectPageKinds(discoveryParams), harvestScrapedCollections),
+		persistenceService: service.NewUserPersistenceService(bgmClient, konomiAccessor, syncedCollectionTypes, vipEvaluator),
 	}
 }
 
@@ -32,13 +51,21 @@ func (orch *ColdStartOrchestrator) Run(numOfSubjectRetrievers, numOfUserIdRetrie
 		Int("coldStartIntervalInDays", coldStartIntervalInDays).
 		Msg("Start cold start orchestrator")
 
-	subjectRetrieverFn := orch.subjectSvc.GetSubjectRetriever(numOfSubjectRetrievers)
+	if !orch.resume {
+		// candidates left by a run that was not resumed are stale
+		if err := orch.candidateStore.Clear(); err != nil {
+			log.Error().Err(err).Msg("Failed to clear the candidate store. Aborting cold start")
+			return
+		}
+	}
+
+	userIdDiscovererFn := service.GetUserIdDiscoverer(orch.getDiscoverers(numOfSubjectRetrievers))
 	userIdRetrieverFn := orch.userIdSvc.GetUserIdRetriever(coldStartIntervalInDays)
-	userMergerFn, userIdSet := orch.userIdSvc.GetUserIdMerger()
+	userMergerFn := orch.userIdSvc.GetUserIdMerger(orch.candidateStore)
 
-	subjectRetriever := pipeline.NewProducer(
-		subjectRetrieverFn,
-		pipeline.Name("Retrieve subject data"),
+	userIdDiscoverer := pipeline.NewProducer(
+		userIdDiscovererFn,
+		pipeline.Name("Discover subjects and user ids"),
 	)
 
 	userIdRetriever := pipeline.NewStage(
@@ -49,22 +76,89 @@ func (orch *ColdStartOrchestrator) Run(numOfSubjectRetrievers, numOfUserIdRetrie
 
 	userMerger := pipeline.NewStage(
 		userMergerFn,
-		pipeline.Name("Merge fetched user ids into one list (only keep unique user ids)"),
+		pipeline.Name("Merge user sightings into the candidate store (only keep unique user ids)"),
 		pipeline.Concurrency(uint(numOfUserIdMergers)),
 	)
 
-	if err := pipeline.Do(
-		subjectRetriever,
+	err := pipeline.Do(
+		userIdDiscoverer,
 		userIdRetriever,
 		userMerger,
-	); err != nil {
+	)
+	orch.userIdSvc.LogParseSummary()
+	if err != nil {
 		log.Error().Err(err).Msg("Failed to run cold start pipeline")
 	} else {
-		log.Info().Msgf("Fetched %d user ids", len(userIdSet))
-		userIds := make([]string, 0, len(userIdSet))
-		for uid := range userIdSet {
-			userIds = append(userIds, uid)
+		if err := orch.persistCandidates(); err != nil {
+			log.Error().Err(err).Msg("Failed to persist candidates")
+			return
+		}
+
+		if err := orch.frontier.Remove(); err != nil {
+			log.Error().Err(err).Msg("Failed to remove scraper checkpoint")
+		}
+		// candidates left unevaluated by maxCandidates are discarded too, the next cold start scrapes them again if they are still around
+		if err := orch.candidateStore.Clear(); err != nil {
+			log.Error().Err(err).Msg("Failed to clear the candidate store")
+		}
+	}
+}
+
+// persistCandidates evaluates the candidates page by page so that they are never all held in memory
+// Candidates seen on more subjects and more recently are evaluated first, so a run cut short has evaluated the most promising ones
+func (orch *ColdStartOrchestrator) persistCandidates() error {
+	candidateCnt, err := orch.candidateStore.Count()
+	if err != nil {
+		return err
+	}
+	log.Info().Msgf("Fetched %d user ids", candidateCnt)
+	if orch.maxCandidates > 0 && candidateCnt > orch.maxCandidates {
+		log.Info().Msgf("Only the top %d candidates will be evaluated in this run, the other %d are discarded", orch.maxCandidates, candidateCnt-orch.maxCandidates)
+		candidateCnt = orch.maxCandidates
+	}
+
+	for offset := 0; offset < candidateCnt; offset += util.CandidateReadBatchSize {
+		candidates, err := orch.candidateStore.Candidates(offset, min(util.CandidateReadBatchSize, candidateCnt-offset))
+		if err != nil {
+			return err
+		}
+		userIds := make([]string, 0, len(candidates))
+		for _, candidate := range candidates {
+			userIds = append(userIds, candidate.UserID)
 		}
 		orch.persistenceService.Persist(userIds)
 	}
+	return nil
+}
+
+func (orch *ColdStartOrchestrator) getDiscoverers(numOfSubjectRetrievers int) []service.UserIdDiscoverer {
+	discoverers := make([]service.UserIdDiscoverer, 0)
+	if len(getSubjectPageKinds(orch.discoveryParams)) > 0 {
+		discoverers = append(discoverers, service.NewSubjectDiscoverer(orch.subjectSvc, numOfSubjectRetrievers))
+	}
+	if orch.discoveryParams.Has(param.GroupMembersSource) {
+		discoverers = append(discoverers, service.NewGroupMemberDiscoverer(orch.discoveryParams.GroupIds, orch.policy, orch.frontier))
+	}
+	if orch.discoveryParams.Has(param.FriendListsSource) {
+		discoverers = append(discoverers, service.NewFriendListDiscoverer(orch.discoveryParams.FriendRootUids, orch.policy, orch.frontier))
+	}
+	if orch.discoveryParams.Has(param.SeedFileSource) {
+		discoverers = append(discoverers, service.NewSeedFileDiscoverer(orch.discoveryParams.SeedUidFile))
+	}
+	return discoverers
+}
+
+// getSubjectPageKinds returns the subject pages to scrape users from
+func getSubjectPageKinds(discoveryParams param.DiscoveryParams) []scraper.SubjectPageKind {
+	kinds := make([]scraper.SubjectPageKind, 0)
+	if discoveryParams.Has(param.SubjectCollectionsSource) {
+		kinds = append(kinds, scraper.SubjectCollectionsPage)
+	}
+	if discoveryParams.Has(param.SubjectDoingsSource) {
+		kinds = append(kinds, scraper.SubjectDoingsPage)
+	}
+	if discoveryParams.Has(param.SubjectWishesSource) {
+		kinds = append(kinds, scraper.SubjectWishesPage)
+	}
+	return kinds
 }
@@ -0,0 +1,64 @@
+package orch
+
+import (
+	"time"
+
+	dao "github.com/AlcEccentric/beck-mizuki/dao"
+	"github.com/AlcEccentric/beck-mizuki/service"
+	"github.com/google/go-pipeline/pkg/pipeline"
+	"github.com/rs/zerolog/log"
+)
+
+type RelationSyncOrchestrator struct {
+	konomiAccessor     dao.KonomiAccessor
+	subjectIdReadSvc   *service.SubjectIdReadingService
+	subjectRelationSvc *service.SubjectRelationService
+}
+
+func NewRelationSyncOrchestrator(bgmClient *dao.BgmApiAccessor, konomiAccessor dao.KonomiAccessor) *RelationSyncOrchestrator {
+	return &RelationSyncOrchestrator{
+		konomiAccessor:     konomiAccessor,
+		subjectIdReadSvc:   service.NewSubjectIdReadingService(),
+		subjectRelationSvc: service.NewSubjectRelationService(bgmClient, konomiAccessor),
+	}
+}
+
+func (orch *RelationSyncOrchestrator) Run(numOfRelationSyncers, batchSize, refreshIntervalInDays int) {
+	log.Info().
+		Int("numOfRelationSyncers", numOfRelationSyncers).
+		Int("batchSize", batchSize).
+		Int("refreshIntervalInDays", refreshIntervalInDays).
+		Msg("Start relation sync orchestrator")
+
+	// 1. read collected subjects whose relations are missing or stale
+	// 2. fetch and persist their relations (and the related subjects)
+	// 3. recompute franchise ids over the whole relation graph
+	syncedBefore := time.Now().AddDate(0, 0, -refreshIntervalInDays)
+	subjectIdReaderFn := orch.subjectIdReadSvc.GetSubjectIdReader(func() ([]string, error) {
+		return orch.konomiAccessor.GetRelationUnsyncedSubjectIds(syncedBefore)
+	}, batchSize)
+	relationSyncerFn := orch.subjectRelationSvc.GetRelationSyncer()
+
+	subjectIdReader := pipeline.NewProducer(
+		subjectIdReaderFn,
+		pipeline.Name("Read subject ids to sync relations for"),
+	)
+
+	relationSyncer := pipeline.NewStage(
+		relationSyncerFn,
+		pipeline.Name("Fetch and persist subject relations"),
+		pipeline.Concurrency(uint(numOfRelationSyncers)),
+	)
+
+	if err := pipeline.Do(
+		subjectIdReader,
+		relationSyncer,
+	); err != nil {
+		log.Error().Err(err).Msg("Failed to run relation sync pipeline")
+		return
+	}
+
+	if err := orch.subjectRelationSvc.UpdateFranchises(); err != nil {
+		log.Error().Err(err).Msg("Failed to update franchise ids")
+	}
+}
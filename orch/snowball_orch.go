@@ -0,0 +1,50 @@
+package orch
+
+import (
+	"github.com/rs/zerolog/log"
+
+	dao "github.com/AlcEccentric/beck-mizuki/dao"
+	"github.com/AlcEccentric/beck-mizuki/helper"
+	model "github.com/AlcEccentric/beck-mizuki/model"
+	table "github.com/AlcEccentric/beck-mizuki/model/gen/beck-konomi/public/table"
+	"github.com/AlcEccentric/beck-mizuki/scraper"
+	"github.com/AlcEccentric/beck-mizuki/service"
+)
+
+// SnowballOrchestrator discovers candidates among the friends of the users already persisted
+type SnowballOrchestrator struct {
+	konomiAccessor     dao.KonomiAccessor
+	friendGraphSvc     *service.FriendGraphService
+	persistenceService *service.UserPersistingService
+}
+
+func NewSnowballOrchestrator(bgmClient *dao.BgmApiAccessor, konomiAccessor dao.KonomiAccessor, syncedCollectionTypes []model.CollectionType,
+	policy *scraper.ScrapingPolicy, vipEvaluator *helper.VipEvaluator) *SnowballOrchestrator {
+	return &SnowballOrchestrator{
+		konomiAccessor:     konomiAccessor,
+		friendGraphSvc:     service.NewFriendGraphService(konomiAccessor, policy),
+		persistenceService: service.NewUserPersistenceService(bgmClient, konomiAccessor, syncedCollectionTypes, vipEvaluator),
+	}
+}
+
+func (orch *SnowballOrchestrator) Run(maxDepth, maxUids int) {
+	log.Info().
+		Int("maxDepth", maxDepth).
+		Int("maxUids", maxUids).
+		Msg("Start snowball orchestrator")
+
+	userCnt, err := orch.konomiAccessor.GetRowCount(table.BgmUser)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to count persisted users")
+		return
+	}
+	seedUids, err := orch.konomiAccessor.GetUserIdsPaginated(0, userCnt)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to read persisted user ids")
+		return
+	}
+
+	discoveredUids := orch.friendGraphSvc.Snowball(seedUids, maxDepth, maxUids)
+	log.Info().Msgf("Discovered %d unseen users from the friend lists of %d persisted users", len(discoveredUids), len(seedUids))
+	orch.persistenceService.Persist(discoveredUids)
+}
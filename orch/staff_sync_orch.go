@@ -0,0 +1,58 @@
+package orch
+
+import (
+	"time"
+
+	dao "github.com/AlcEccentric/beck-mizuki/dao"
+	"github.com/AlcEccentric/beck-mizuki/service"
+	"github.com/google/go-pipeline/pkg/pipeline"
+	"github.com/rs/zerolog/log"
+)
+
+type StaffSyncOrchestrator struct {
+	konomiAccessor   dao.KonomiAccessor
+	subjectIdReadSvc *service.SubjectIdReadingService
+	subjectStaffSvc  *service.SubjectStaffService
+}
+
+func NewStaffSyncOrchestrator(bgmClient *dao.BgmApiAccessor, konomiAccessor dao.KonomiAccessor) *StaffSyncOrchestrator {
+	return &StaffSyncOrchestrator{
+		konomiAccessor:   konomiAccessor,
+		subjectIdReadSvc: service.NewSubjectIdReadingService(),
+		subjectStaffSvc:  service.NewSubjectStaffService(bgmClient, konomiAccessor),
+	}
+}
+
+func (orch *StaffSyncOrchestrator) Run(numOfStaffSyncers, batchSize, refreshIntervalInDays int) {
+	log.Info().
+		Int("numOfStaffSyncers", numOfStaffSyncers).
+		Int("batchSize", batchSize).
+		Int("refreshIntervalInDays", refreshIntervalInDays).
+		Msg("Start staff sync orchestrator")
+
+	// 1. read collected subjects whose staff and characters are missing or stale
+	// 2. fetch and persist persons, characters and their roles in the subjects
+	syncedBefore := time.Now().AddDate(0, 0, -refreshIntervalInDays)
+	subjectIdReaderFn := orch.subjectIdReadSvc.GetSubjectIdReader(func() ([]string, error) {
+		return orch.konomiAccessor.GetStaffUnsyncedSubjectIds(syncedBefore)
+	}, batchSize)
+	staffSyncerFn := orch.subjectStaffSvc.GetStaffSyncer()
+
+	subjectIdReader := pipeline.NewProducer(
+		subjectIdReaderFn,
+		pipeline.Name("Read subject ids to sync staff for"),
+	)
+
+	staffSyncer := pipeline.NewStage(
+		staffSyncerFn,
+		pipeline.Name("Fetch and persist subject staff and characters"),
+		pipeline.Concurrency(uint(numOfStaffSyncers)),
+	)
+
+	if err := pipeline.Do(
+		subjectIdReader,
+		staffSyncer,
+	); err != nil {
+		log.Error().Err(err).Msg("Failed to run staff sync pipeline")
+	}
+}
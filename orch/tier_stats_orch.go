@@ -0,0 +1,79 @@
+package orch
+
+import (
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	dao "github.com/AlcEccentric/beck-mizuki/dao"
+	model "github.com/AlcEccentric/beck-mizuki/model"
+)
+
+const tierStatsInsertBatchSize = 50
+
+// TierStatsOrchestrator reports the tier distribution of active users and how it changed since the previous stats run
+type TierStatsOrchestrator struct {
+	konomiAccessor dao.KonomiAccessor
+}
+
+func NewTierStatsOrchestrator(konomiAccessor dao.KonomiAccessor) *TierStatsOrchestrator {
+	return &TierStatsOrchestrator{
+		konomiAccessor: konomiAccessor,
+	}
+}
+
+func (orch *TierStatsOrchestrator) Run() {
+	log.Info().Msg("Start tier stats orchestrator")
+
+	tierCounts, err := orch.konomiAccessor.GetTierCounts()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to count users per tier")
+		return
+	}
+	previousStats, err := orch.konomiAccessor.GetLatestTierStats()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to read the previous tier stats")
+		return
+	}
+	previousCounts := make(map[int]int, len(previousStats))
+	for _, stat := range previousStats {
+		previousCounts[stat.Tier] = stat.UserCnt
+	}
+	if len(previousStats) == 0 {
+		log.Info().Msg("No previous tier stats, changes are counted from zero")
+	} else {
+		log.Info().Msgf("Comparing with tier stats of %s", previousStats[0].RunAt.Format(time.RFC3339))
+	}
+
+	runAt := time.Now()
+	stats := make([]model.TierStat, 0, len(tierCounts))
+	for _, tier := range sortedTiers(tierCounts, previousCounts) {
+		log.Info().
+			Int("tier", tier).
+			Int("users", tierCounts[tier]).
+			Int("previousUsers", previousCounts[tier]).
+			Int("change", tierCounts[tier]-previousCounts[tier]).
+			Msg("Tier stats")
+		stats = append(stats, model.TierStat{RunAt: runAt, Tier: tier, UserCnt: tierCounts[tier]})
+	}
+
+	if err := orch.konomiAccessor.BatchInsertTierStats(stats, tierStatsInsertBatchSize); err != nil {
+		log.Error().Err(err).Msg("Failed to save tier stats")
+	}
+}
+
+// sortedTiers returns the tiers found now or in the previous run
+func sortedTiers(tierCounts, previousCounts map[int]int) []int {
+	tiers := make([]int, 0, len(tierCounts))
+	for tier := range tierCounts {
+		tiers = append(tiers, tier)
+	}
+	for tier := range previousCounts {
+		if _, ok := tierCounts[tier]; !ok {
+			tiers = append(tiers, tier)
+		}
+	}
+	sort.Ints(tiers)
+	return tiers
+}
@@ -0,0 +1,45 @@
+package orch
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	dao "github.com/AlcEccentric/beck-mizuki/dao"
+	"github.com/AlcEccentric/beck-mizuki/util"
+)
+
+// TimeZoneBackfillOrchestrator fixes the data scraped before website times were read in the site zone
+// Such times were read as UTC, so they are late by the offset of the site zone, and so are the watermarks moved on them
+// It is meant to run once after upgrading, running it again changes nothing but the watermarks set since
+type TimeZoneBackfillOrchestrator struct {
+	konomiAccessor dao.KonomiAccessor
+}
+
+func NewTimeZoneBackfillOrchestrator(konomiAccessor dao.KonomiAccessor) *TimeZoneBackfillOrchestrator {
+	return &TimeZoneBackfillOrchestrator{
+		konomiAccessor: konomiAccessor,
+	}
+}
+
+func (orch *TimeZoneBackfillOrchestrator) Run() {
+	log.Info().Msg("Start time zone backfill orchestrator")
+
+	// the site zone keeps the same offset all year
+	_, offsetSeconds := time.Now().In(util.SiteLocation).Zone()
+	lateBy := time.Duration(offsetSeconds) * time.Second
+	shiftedCnt, err := orch.konomiAccessor.ShiftUnzonedScrapedCollections(lateBy, util.SiteTimeZone)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to shift scraped collections stored without a zone")
+		return
+	}
+	log.Info().Msgf("Moved %d scraped collections back by %s", shiftedCnt, lateBy)
+
+	// watermarks cannot tell if they were set before the fix, and one set too late would skip new collections
+	resetCnt, err := orch.konomiAccessor.ResetScrapeWatermarks()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to reset scrape watermarks")
+		return
+	}
+	log.Info().Msgf("Reset scrape watermarks of %d subject pages, the next cold start scrapes them up to its interval", resetCnt)
+}
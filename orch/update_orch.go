@@ -2,6 +2,8 @@ package orch
 
 import (
 	dao "github.com/AlcEccentric/beck-mizuki/dao"
+	"github.com/AlcEccentric/beck-mizuki/helper"
+	model "github.com/AlcEccentric/beck-mizuki/model"
 	"github.com/AlcEccentric/beck-mizuki/service"
 	"github.com/google/go-pipeline/pkg/pipeline"
 	"github.com/rs/zerolog/log"
@@ -14,12 +16,13 @@ type UpdateOrchestrator struct {
 	userCleaningSvc  *service.UserCleaningService
 }
 
-func NewUpdateOrchestrator(bgmClient *dao.BgmApiAccessor, konomiAccessor dao.KonomiAccessor) *UpdateOrchestrator {
+func NewUpdateOrchestrator(bgmClient *dao.BgmApiAccessor, konomiAccessor dao.KonomiAccessor, syncedCollectionTypes []model.CollectionType,
+	vipEvaluator *helper.VipEvaluator, strikesBeforeRemoval int) *UpdateOrchestrator {
 	return &UpdateOrchestrator{
 		bgmClient:        bgmClient,
 		userIdReadingSvc: service.NewUserIdReadingService(konomiAccessor),
-		userUpdatingSvc:  service.NewUserUpdatingService(bgmClient, konomiAccessor),
-		userCleaningSvc:  service.NewUserCleaningService(konomiAccessor),
+		userUpdatingSvc:  service.NewUserUpdatingService(bgmClient, konomiAccessor, syncedCollectionTypes, vipEvaluator),
+		userCleaningSvc:  service.NewUserCleaningService(konomiAccessor, strikesBeforeRemoval),
 	}
 }
 
@@ -36,7 +39,8 @@ func (orch *UpdateOrchestrator) Run(numOfUserIdReaders, numOfCollectionUpdater,
 	// 2.1. Upsert user info into db
 	// 2.2. Insert new collections since last active time (also update last active time for the user)
 	// 3. Fail:
-	// 3.1. remove user & collections
+	// 3.1. mark user inactive
+	// 3.2. remove user & collections if the user failed strikesBeforeRemoval checks in a row
 	userIdReaderFn := orch.userIdReadingSvc.GetUserIdReader(numOfUserIdReaders)
 	userUpdaterFn := orch.userUpdatingSvc.GetUserUpdater()
 	userCleanerFn := orch.userCleaningSvc.GetUserCleaner()
@@ -53,7 +57,7 @@ func (orch *UpdateOrchestrator) Run(numOfUserIdReaders, numOfCollectionUpdater,
 
 	userCleaner := pipeline.NewStage(
 		userCleanerFn,
-		pipeline.Name("Clean up inactive users"),
+		pipeline.Name("Mark inactive users and clean up the ones out of strikes"),
 	)
 
 	if err := pipeline.Do(
@@ -0,0 +1,152 @@
+package orch
+
+import (
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	dao "github.com/AlcEccentric/beck-mizuki/dao"
+	"github.com/AlcEccentric/beck-mizuki/helper"
+	model "github.com/AlcEccentric/beck-mizuki/model"
+	table "github.com/AlcEccentric/beck-mizuki/model/gen/beck-konomi/public/table"
+	"github.com/AlcEccentric/beck-mizuki/service"
+	"github.com/AlcEccentric/beck-mizuki/util"
+)
+
+// VipSimulationOrchestrator reports how many persisted users and evaluated candidates would be VIPs with other thresholds at other dates
+// and how well the activity model of each threshold set predicts which users keep watching
+type VipSimulationOrchestrator struct {
+	konomiAccessor dao.KonomiAccessor
+	simulationSvc  *service.VipSimulationService
+}
+
+func NewVipSimulationOrchestrator(konomiAccessor dao.KonomiAccessor) *VipSimulationOrchestrator {
+	return &VipSimulationOrchestrator{
+		konomiAccessor: konomiAccessor,
+		simulationSvc:  service.NewVipSimulationService(konomiAccessor),
+	}
+}
+
+func (orch *VipSimulationOrchestrator) Run(thresholdSets map[string]helper.VipThresholds, ruleNames []string, asOfDates []time.Time) {
+	log.Info().
+		Int("numOfThresholdSets", len(thresholdSets)).
+		Strs("rules", ruleNames).
+		Int("numOfAsOfDates", len(asOfDates)).
+		Msg("Start vip simulation orchestrator")
+
+	reports, err := newSimulationReports(thresholdSets, ruleNames, asOfDates)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to build the simulated rules")
+		return
+	}
+	todayVips, err := orch.getTodayVips()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to read today's vips")
+		return
+	}
+	uids, err := orch.getSimulatedUids()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to read the users to simulate")
+		return
+	}
+
+	for i, uid := range uids {
+		history, err := orch.simulationSvc.GetHistory(uid)
+		if err != nil {
+			log.Error().Err(err).Msgf("Failed to read collection history of user: %s. Skipping.", uid)
+			continue
+		}
+		orch.simulationSvc.Simulate(uid, history, reports)
+		if (i+1)%util.CandidateReadBatchSize == 0 {
+			log.Info().Msgf("Simulated %d/%d users", i+1, len(uids))
+		}
+	}
+
+	for _, report := range reports {
+		both, simulatedOnly, todayOnly := report.Overlap(todayVips)
+		event := log.Info().
+			Str("thresholdSet", report.ThresholdSet).
+			Str("asOf", report.AsOf.Format(util.SubjectDateFormat)).
+			Int("evaluated", report.Evaluated).
+			Int("errored", report.Errored)
+		for _, rule := range report.Rules {
+			event = event.Int("passed_"+rule.Name(), report.PassedByRule[rule.Name()])
+		}
+		event.
+			Int("vips", len(report.Vips)).
+			Int("todayVips", len(todayVips)).
+			Int("vipsToday", both).
+			Int("vipsSimulatedOnly", simulatedOnly).
+			Int("vipsTodayOnly", todayOnly).
+			Str("activityModel", report.Thresholds.ActivityModel).
+			Int("predictedActive", report.PredictedActive).
+			Int("predictedActiveStayed", report.PredictedActiveStayed).
+			Int("predictedInactive", report.PredictedInactive).
+			Int("predictedInactiveStayed", report.PredictedInactiveStayed).
+			Msg("Simulation report")
+	}
+}
+
+// newSimulationReports returns a report for each threshold set and date, ordered by threshold set name then date
+func newSimulationReports(thresholdSets map[string]helper.VipThresholds, ruleNames []string, asOfDates []time.Time) ([]*service.SimulationReport, error) {
+	names := make([]string, 0, len(thresholdSets))
+	for name := range thresholdSets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	reports := make([]*service.SimulationReport, 0, len(names)*len(asOfDates))
+	for _, name := range names {
+		ruleSets, err := helper.NewRuleSets(map[model.SubjectType][]string{model.Anime: ruleNames}, thresholdSets[name])
+		if err != nil {
+			return nil, err
+		}
+		activityRule, err := helper.NewRule(helper.ActivityRuleName, thresholdSets[name])
+		if err != nil {
+			return nil, err
+		}
+		for _, asOf := range asOfDates {
+			reports = append(reports, service.NewSimulationReport(name, asOf, ruleSets[model.Anime], activityRule, thresholdSets[name]))
+		}
+	}
+	return reports, nil
+}
+
+func (orch *VipSimulationOrchestrator) getTodayVips() (map[string]struct{}, error) {
+	activeUids, err := orch.konomiAccessor.GetActiveUserIds()
+	if err != nil {
+		return nil, err
+	}
+	todayVips := make(map[string]struct{}, len(activeUids))
+	for _, uid := range activeUids {
+		todayVips[uid] = struct{}{}
+	}
+	return todayVips, nil
+}
+
+// getSimulatedUids returns the persisted users and the candidates in the ledger
+func (orch *VipSimulationOrchestrator) getSimulatedUids() ([]string, error) {
+	userCnt, err := orch.konomiAccessor.GetRowCount(table.BgmUser)
+	if err != nil {
+		return nil, err
+	}
+	userIds, err := orch.konomiAccessor.GetUserIdsPaginated(0, userCnt)
+	if err != nil {
+		return nil, err
+	}
+	candidateIds, err := orch.konomiAccessor.GetEvaluatedCandidateIds()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]struct{}, len(userIds)+len(candidateIds))
+	uids := make([]string, 0, len(userIds)+len(candidateIds))
+	for _, uid := range append(userIds, candidateIds...) {
+		if _, ok := seen[uid]; !ok {
+			seen[uid] = struct{}{}
+			uids = append(uids, uid)
+		}
+	}
+	return uids, nil
+}
@@ -0,0 +1,90 @@
+package param
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/AlcEccentric/beck-mizuki/util"
+	"github.com/rs/zerolog/log"
+)
+
+type DiscoverySource string
+
+const (
+	SubjectCollectionsSource DiscoverySource = "subject_collections"
+	SubjectDoingsSource      DiscoverySource = "subject_doings"
+	SubjectWishesSource      DiscoverySource = "subject_wishes"
+	GroupMembersSource       DiscoverySource = "group_members"
+	FriendListsSource        DiscoverySource = "friend_lists"
+	SeedFileSource           DiscoverySource = "seed_file"
+)
+
+func DiscoverySourceFromString(sourceStr string) (DiscoverySource, error) {
+	switch source := DiscoverySource(sourceStr); source {
+	case SubjectCollectionsSource, SubjectDoingsSource, SubjectWishesSource, GroupMembersSource, FriendListsSource, SeedFileSource:
+		return source, nil
+	default:
+		return "", fmt.Errorf("discovery source %s is not supported", sourceStr)
+	}
+}
+
+// DiscoveryParams configures where cold start looks for candidate users
+type DiscoveryParams struct {
+	Sources        []DiscoverySource
+	GroupIds       []string
+	FriendRootUids []string
+	SeedUidFile    string
+}
+
+func (params DiscoveryParams) Has(source DiscoverySource) bool {
+	for _, s := range params.Sources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
+func getDiscoveryParams() DiscoveryParams {
+	discoverySources := os.Getenv("DISCOVERY_SOURCES")
+	if discoverySources == "" {
+		discoverySources = util.DiscoverySources
+	}
+
+	params := DiscoveryParams{
+		GroupIds:       splitList(os.Getenv("DISCOVERY_GROUPS")),
+		FriendRootUids: splitList(os.Getenv("DISCOVERY_FRIEND_ROOT_UIDS")),
+		SeedUidFile:    os.Getenv("DISCOVERY_SEED_UID_FILE"),
+	}
+	for _, sourceStr := range splitList(discoverySources) {
+		source, err := DiscoverySourceFromString(sourceStr)
+		if err != nil {
+			log.Fatal().Err(err).Msgf("Failed to parse DISCOVERY_SOURCES %s", discoverySources)
+		}
+		params.Sources = append(params.Sources, source)
+	}
+
+	if params.Has(GroupMembersSource) && len(params.GroupIds) == 0 {
+		log.Fatal().Msg("DISCOVERY_GROUPS environment variable is not set while discovering group members")
+	}
+	if params.Has(FriendListsSource) && len(params.FriendRootUids) == 0 {
+		log.Fatal().Msg("DISCOVERY_FRIEND_ROOT_UIDS environment variable is not set while discovering friend lists")
+	}
+	if params.Has(SeedFileSource) && params.SeedUidFile == "" {
+		log.Fatal().Msg("DISCOVERY_SEED_UID_FILE environment variable is not set while discovering from a seed file")
+	}
+	log.Info().Msgf("Discovering users from: %s", discoverySources)
+	return params
+}
+
+// splitList splits a comma separated list, dropping empty items
+func splitList(list string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
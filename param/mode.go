@@ -8,6 +8,13 @@ const (
 	ColdStartMode ExecutionMode = iota
 	RegularUpdateMode
 	DirectlyExitMode
+	RelationSyncMode
+	StaffSyncMode
+	ArchiveImportMode
+	SnowballMode
+	SimulateMode
+	StatsMode
+	TimeZoneBackfillMode
 )
 
 func CrawlerModeFromString(modeStr string) (mode ExecutionMode, err error) {
@@ -18,6 +25,20 @@ func CrawlerModeFromString(modeStr string) (mode ExecutionMode, err error) {
 		return RegularUpdateMode, nil
 	case "exit":
 		return DirectlyExitMode, nil
+	case "relation":
+		return RelationSyncMode, nil
+	case "staff":
+		return StaffSyncMode, nil
+	case "archive":
+		return ArchiveImportMode, nil
+	case "snowball":
+		return SnowballMode, nil
+	case "simulate":
+		return SimulateMode, nil
+	case "stats":
+		return StatsMode, nil
+	case "tzbackfill":
+		return TimeZoneBackfillMode, nil
 	default:
 		return -1, fmt.Errorf("mode %s is not supported", modeStr)
 	}
@@ -31,6 +52,20 @@ func (mode ExecutionMode) String() string {
 		return "regular"
 	case DirectlyExitMode:
 		return "exit"
+	case RelationSyncMode:
+		return "relation"
+	case StaffSyncMode:
+		return "staff"
+	case ArchiveImportMode:
+		return "archive"
+	case SnowballMode:
+		return "snowball"
+	case SimulateMode:
+		return "simulate"
+	case StatsMode:
+		return "stats"
+	case TimeZoneBackfillMode:
+		return "tzbackfill"
 	default:
 		return ""
 	}
@@ -4,8 +4,13 @@ import (
 	"flag"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/AlcEccentric/beck-mizuki/helper"
+	"github.com/AlcEccentric/beck-mizuki/model"
+	"github.com/AlcEccentric/beck-mizuki/proxy"
+	"github.com/AlcEccentric/beck-mizuki/scraper"
 	"github.com/AlcEccentric/beck-mizuki/util"
 	"github.com/rs/zerolog/log"
 )
@@ -13,18 +18,96 @@ import (
 type Params struct {
 	Mode                    ExecutionMode
 	ColdStartIntervalInDays int
+	SyncedCollectionTypes   []model.CollectionType
+	ArchiveDumpPath         string
+	Resume                  bool
+	ScraperCheckpointPath   string
+	// stage full collection records (rating, comment, etc.) found while scraping users
+	HarvestScrapedCollections bool
+	Discovery                 DiscoveryParams
+	SnowballMaxDepth          int
+	SnowballMaxUids           int
+	ScrapingPolicy            *scraper.ScrapingPolicy
+	ProxyPool                 *proxy.Pool // nil if requests are sent directly
+	MaxCandidatesPerRun       int         // 0 to evaluate all candidates found by a cold start, candidates beyond it are discarded
+	VipThresholds             helper.VipThresholds
+	VipRuleSets               map[model.SubjectType][]helper.Rule
+	BotDetectionAction        helper.BotDetectionAction
+	CandidateCooldownInDays   int // 0 to evaluate rejected candidates again on every cold start
+	SubjectFilter             *helper.SubjectFilter
+	// users are marked inactive on a failed activity check, and removed after this many failed checks in a row
+	InactiveStrikesBeforeRemoval int
+	Simulation                   SimulationParams // only set in simulate mode
+	// activity windows and scraping horizons are counted in calendar days of this zone
+	CalendarLocation *time.Location
 }
 
 func GetParams() (params Params) {
 	var modeStr string
-	flag.StringVar(&modeStr, "mode", "", "mode: "+ColdStartMode.String()+" or "+RegularUpdateMode.String())
+	var resume bool
+	flag.StringVar(&modeStr, "mode", "", "mode: "+ColdStartMode.String()+", "+RegularUpdateMode.String()+", "+RelationSyncMode.String()+", "+StaffSyncMode.String()+", "+ArchiveImportMode.String()+", "+SnowballMode.String()+", "+SimulateMode.String()+", "+StatsMode.String()+" or "+TimeZoneBackfillMode.String())
+	flag.BoolVar(&resume, "resume", false, "resume cold start from the last scraper checkpoint")
 	flag.Parse()
 	log.Info().Msgf("Retrieving CrawlerMode from flag arg string: %s", modeStr)
 
-	return Params{
-		Mode:                    getMode(modeStr),
-		ColdStartIntervalInDays: getColdStartIntervalInDays(),
+	params = Params{
+		Mode:                         getMode(modeStr),
+		ColdStartIntervalInDays:      getColdStartIntervalInDays(),
+		SyncedCollectionTypes:        getSyncedCollectionTypes(),
+		ArchiveDumpPath:              os.Getenv("ARCHIVE_DUMP_PATH"),
+		Resume:                       resume,
+		ScraperCheckpointPath:        getScraperCheckpointPath(),
+		HarvestScrapedCollections:    getHarvestScrapedCollections(),
+		Discovery:                    getDiscoveryParams(),
+		SnowballMaxDepth:             getPositiveInt("SNOWBALL_MAX_DEPTH", util.SnowballMaxDepth),
+		SnowballMaxUids:              getPositiveInt("SNOWBALL_MAX_UIDS", util.SnowballMaxUids),
+		ScrapingPolicy:               getScrapingPolicy(),
+		ProxyPool:                    getProxyPool(),
+		MaxCandidatesPerRun:          getNonNegativeInt("MAX_CANDIDATES_PER_RUN", 0),
+		CandidateCooldownInDays:      getNonNegativeInt("CANDIDATE_COOLDOWN_IN_DAYS", util.CandidateCooldownInDays),
+		SubjectFilter:                getSubjectFilter(),
+		InactiveStrikesBeforeRemoval: getPositiveInt("INACTIVE_STRIKES_BEFORE_REMOVAL", util.InactiveStrikesBeforeRemoval),
+		CalendarLocation:             getCalendarLocation(),
 	}
+	params.ScrapingPolicy.Transport = params.Transport()
+	params.ScrapingPolicy.CalendarLocation = params.CalendarLocation
+	params.VipThresholds = helper.DefaultVipThresholds()
+	params.VipThresholds.CalendarLocation = params.CalendarLocation
+	params.VipThresholds.MinQualityScore = getVipScoreThreshold()
+	params.VipThresholds.ActivityModel = getActivityModel(params.VipThresholds)
+	params.BotDetectionAction = getBotDetectionAction()
+	params.VipRuleSets = getVipRuleSets(params.VipThresholds, params.BotDetectionAction)
+	if params.Mode == SimulateMode {
+		params.Simulation = getSimulationParams(params.VipThresholds)
+	}
+	return params
+}
+
+func getScraperCheckpointPath() string {
+	scraperCheckpointPath := os.Getenv("SCRAPER_CHECKPOINT_PATH")
+	if scraperCheckpointPath == "" {
+		return util.ScraperCheckpointPath
+	}
+	return scraperCheckpointPath
+}
+
+func getSyncedCollectionTypes() []model.CollectionType {
+	syncedCollectionTypes := os.Getenv("SYNCED_COLLECTION_TYPES")
+	if syncedCollectionTypes == "" {
+		log.Warn().Msg("SYNCED_COLLECTION_TYPES environment variable is not set")
+		syncedCollectionTypes = util.SyncedCollectionTypes
+	}
+
+	ctypes := make([]model.CollectionType, 0)
+	for _, ctypeStr := range strings.Split(syncedCollectionTypes, ",") {
+		ctype, err := model.CollectionTypeFromString(strings.TrimSpace(ctypeStr))
+		if err != nil {
+			log.Fatal().Err(err).Msgf("Failed to parse SYNCED_COLLECTION_TYPES %s", syncedCollectionTypes)
+		}
+		ctypes = append(ctypes, ctype)
+	}
+	log.Info().Msgf("Syncing collection types: %s", syncedCollectionTypes)
+	return ctypes
 }
 
 func getColdStartIntervalInDays() int {
@@ -70,3 +153,64 @@ func getMode(modeStr string) ExecutionMode {
 		return mode
 	}
 }
+
+func getHarvestScrapedCollections() bool {
+	harvestScrapedCollections := os.Getenv("HARVEST_SCRAPED_COLLECTIONS")
+	if harvestScrapedCollections == "" {
+		return false
+	}
+	harvestScrapedCollectionsBool, err := strconv.ParseBool(harvestScrapedCollections)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to parse HARVEST_SCRAPED_COLLECTIONS %s", harvestScrapedCollections)
+	}
+	return harvestScrapedCollectionsBool
+}
+
+func getPositiveInt(envName string, defaultValue int) int {
+	valueStr := os.Getenv(envName)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.Atoi(valueStr)
+	if err != nil || value <= 0 {
+		log.Fatal().Err(err).Msgf("Failed to parse %s %s as a positive integer", envName, valueStr)
+	}
+	return value
+}
+
+func getNonNegativeInt(envName string, defaultValue int) int {
+	valueStr := os.Getenv(envName)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.Atoi(valueStr)
+	if err != nil || value < 0 {
+		log.Fatal().Err(err).Msgf("Failed to parse %s %s as a non-negative integer", envName, valueStr)
+	}
+	return value
+}
+
+func getVipScoreThreshold() float64 {
+	vipScoreThreshold := os.Getenv("VIP_SCORE_THRESHOLD")
+	if vipScoreThreshold == "" {
+		return util.MinQualityScore
+	}
+	vipScoreThresholdFloat, err := strconv.ParseFloat(vipScoreThreshold, 64)
+	if err != nil || vipScoreThresholdFloat < 0 || vipScoreThresholdFloat > 1 {
+		log.Fatal().Err(err).Msgf("Failed to parse VIP_SCORE_THRESHOLD %s as a score in [0, 1]", vipScoreThreshold)
+	}
+	return vipScoreThresholdFloat
+}
+
+func getCalendarLocation() *time.Location {
+	calendarTimeZone := os.Getenv("CALENDAR_TIME_ZONE")
+	if calendarTimeZone == "" {
+		return util.DefaultCalendarLocation
+	}
+	calendarLocation, err := time.LoadLocation(calendarTimeZone)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to load CALENDAR_TIME_ZONE %s", calendarTimeZone)
+	}
+	log.Info().Msgf("Counting calendar days in %s", calendarTimeZone)
+	return calendarLocation
+}
@@ -0,0 +1,44 @@
+package param
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/AlcEccentric/beck-mizuki/proxy"
+	"github.com/AlcEccentric/beck-mizuki/util"
+	"github.com/rs/zerolog/log"
+)
+
+// getProxyPool returns nil if no proxy is configured, in which case requests are sent directly
+func getProxyPool() *proxy.Pool {
+	proxyUrls := splitList(os.Getenv("PROXY_URLS"))
+	if len(proxyUrls) == 0 {
+		return nil
+	}
+
+	assignmentStr := os.Getenv("PROXY_ASSIGNMENT")
+	if assignmentStr == "" {
+		assignmentStr = util.ProxyAssignment
+	}
+	assignment, err := proxy.AssignmentFromString(assignmentStr)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to parse PROXY_ASSIGNMENT %s", assignmentStr)
+	}
+
+	pool, err := proxy.NewPool(proxyUrls, assignment, util.ProxyMaxConsecutiveErrors,
+		util.ProxyThrottleBackoffInS*time.Second, util.ProxyMaxThrottleBackoffInS*time.Second)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to parse PROXY_URLS")
+	}
+	log.Info().Msgf("Sending requests through %d proxies with %s assignment", len(proxyUrls), assignmentStr)
+	return pool
+}
+
+// Transport returns the round tripper shared by the scraper and the API client, nil to use the default one
+func (params Params) Transport() http.RoundTripper {
+	if params.ProxyPool == nil {
+		return nil
+	}
+	return params.ProxyPool
+}
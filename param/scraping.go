@@ -0,0 +1,82 @@
+package param
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlcEccentric/beck-mizuki/scraper"
+	"github.com/AlcEccentric/beck-mizuki/util"
+	"github.com/rs/zerolog/log"
+)
+
+func getScrapingPolicy() *scraper.ScrapingPolicy {
+	mirrors := os.Getenv("SCRAPER_MIRRORS")
+	if mirrors == "" {
+		mirrors = util.ScraperMirrors
+	}
+
+	domainRules := make(map[string]scraper.DomainRule)
+	// e.g. SCRAPER_DOMAIN_RULES=bangumi.tv=1/2s/2s,bgm.tv=2/1s/500ms (parallelism/delay/random delay)
+	for _, ruleStr := range splitList(os.Getenv("SCRAPER_DOMAIN_RULES")) {
+		rule, err := parseDomainRule(ruleStr)
+		if err != nil {
+			log.Fatal().Err(err).Msgf("Failed to parse SCRAPER_DOMAIN_RULES %s", ruleStr)
+		}
+		domainRules[rule.Domain] = rule
+	}
+
+	respectRobotsTxt := false
+	if respectRobotsTxtStr := os.Getenv("SCRAPER_RESPECT_ROBOTS_TXT"); respectRobotsTxtStr != "" {
+		var err error
+		if respectRobotsTxt, err = strconv.ParseBool(respectRobotsTxtStr); err != nil {
+			log.Fatal().Err(err).Msgf("Failed to parse SCRAPER_RESPECT_ROBOTS_TXT %s", respectRobotsTxtStr)
+		}
+	}
+
+	parserVersion := os.Getenv("SCRAPER_PARSER_VERSION")
+	if parserVersion == "" {
+		parserVersion = util.SubjectPageParserVersion
+	}
+	subjectPageParser, err := scraper.GetSubjectPageParser(parserVersion)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to parse SCRAPER_PARSER_VERSION %s", parserVersion)
+	}
+
+	log.Info().Msgf("Scraping mirrors: %s with parser %s", mirrors, parserVersion)
+	return &scraper.ScrapingPolicy{
+		Mirrors:           scraper.NewMirrorPool(splitList(mirrors), util.MirrorFailoverErrorThreshold),
+		DomainRules:       domainRules,
+		RespectRobotsTxt:  respectRobotsTxt,
+		SubjectPageParser: subjectPageParser,
+	}
+}
+
+func parseDomainRule(ruleStr string) (scraper.DomainRule, error) {
+	domain, settings, found := strings.Cut(ruleStr, "=")
+	parts := strings.Split(settings, "/")
+	if !found || len(parts) != 3 {
+		return scraper.DomainRule{}, fmt.Errorf("domain rule %s is not in the form domain=parallelism/delay/randomDelay", ruleStr)
+	}
+
+	parallelism, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return scraper.DomainRule{}, err
+	}
+	delay, err := time.ParseDuration(parts[1])
+	if err != nil {
+		return scraper.DomainRule{}, err
+	}
+	randomDelay, err := time.ParseDuration(parts[2])
+	if err != nil {
+		return scraper.DomainRule{}, err
+	}
+	return scraper.DomainRule{
+		Domain:      strings.TrimSpace(domain),
+		Parallelism: parallelism,
+		Delay:       delay,
+		RandomDelay: randomDelay,
+	}, nil
+}
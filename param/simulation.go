@@ -0,0 +1,44 @@
+package param
+
+import (
+	"os"
+	"time"
+
+	"github.com/AlcEccentric/beck-mizuki/helper"
+	"github.com/AlcEccentric/beck-mizuki/util"
+	"github.com/rs/zerolog/log"
+)
+
+// currentThresholdSet is the name of the configured thresholds, always simulated as a baseline
+const currentThresholdSet = "current"
+
+// SimulationParams configures the threshold sets and the dates VIP rules are replayed with
+type SimulationParams struct {
+	ThresholdSets map[string]helper.VipThresholds
+	AsOfDates     []time.Time
+}
+
+func getSimulationParams(currentThresholds helper.VipThresholds) SimulationParams {
+	params := SimulationParams{ThresholdSets: map[string]helper.VipThresholds{}}
+	if thresholdsPath := os.Getenv("SIMULATION_THRESHOLDS_PATH"); thresholdsPath != "" {
+		thresholdSets, err := helper.LoadThresholdSets(thresholdsPath, currentThresholds)
+		if err != nil {
+			log.Fatal().Err(err).Msgf("Failed to load SIMULATION_THRESHOLDS_PATH %s", thresholdsPath)
+		}
+		params.ThresholdSets = thresholdSets
+	}
+	params.ThresholdSets[currentThresholdSet] = currentThresholds
+
+	// e.g. SIMULATION_AS_OF=2024-01-01,2024-07-01
+	for _, asOfStr := range splitList(os.Getenv("SIMULATION_AS_OF")) {
+		asOf, err := time.Parse(util.SubjectDateFormat, asOfStr)
+		if err != nil {
+			log.Fatal().Err(err).Msgf("Failed to parse SIMULATION_AS_OF %s", asOfStr)
+		}
+		params.AsOfDates = append(params.AsOfDates, asOf)
+	}
+	if len(params.AsOfDates) == 0 {
+		params.AsOfDates = []time.Time{time.Now()}
+	}
+	return params
+}
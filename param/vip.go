@@ -0,0 +1,93 @@
+package param
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/AlcEccentric/beck-mizuki/helper"
+	"github.com/AlcEccentric/beck-mizuki/model"
+	"github.com/AlcEccentric/beck-mizuki/util"
+	"github.com/rs/zerolog/log"
+)
+
+func getVipRuleSets(thresholds helper.VipThresholds, botDetectionAction helper.BotDetectionAction) map[model.SubjectType][]helper.Rule {
+	vipRules := os.Getenv("VIP_RULES")
+	if vipRules == "" {
+		vipRules = util.VipRules
+	}
+
+	// e.g. VIP_RULES=anime=raw_watched_count,activity;game=raw_watched_count
+	ruleNames := make(map[model.SubjectType][]string)
+	for _, ruleSetStr := range strings.Split(vipRules, ";") {
+		subjectType, names, err := parseVipRuleSet(ruleSetStr)
+		if err != nil {
+			log.Fatal().Err(err).Msgf("Failed to parse VIP_RULES %s", vipRules)
+		}
+		ruleNames[subjectType] = names
+	}
+
+	ruleSets, err := helper.NewRuleSets(ruleNames, thresholds)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to parse VIP_RULES %s", vipRules)
+	}
+	helper.ApplyBotDetectionAction(ruleSets, botDetectionAction)
+	if _, ok := ruleSets[model.Anime]; !ok {
+		log.Fatal().Msgf("VIP_RULES %s has no rule set for anime", vipRules)
+	}
+	log.Info().Msgf("Evaluating VIPs with rules: %s", vipRules)
+	return ruleSets
+}
+
+func getBotDetectionAction() helper.BotDetectionAction {
+	botDetectionAction := os.Getenv("BOT_DETECTION_ACTION")
+	if botDetectionAction == "" {
+		botDetectionAction = util.BotDetectionAction
+	}
+	switch action := helper.BotDetectionAction(botDetectionAction); action {
+	case helper.FlagBots, helper.ExcludeBots:
+		return action
+	default:
+		log.Fatal().Msgf("BOT_DETECTION_ACTION %s is not supported", botDetectionAction)
+		return ""
+	}
+}
+
+func getActivityModel(thresholds helper.VipThresholds) string {
+	activityModel := os.Getenv("ACTIVITY_MODEL")
+	if activityModel == "" {
+		activityModel = util.ActivityModel
+	}
+	thresholds.ActivityModel = activityModel
+	if _, err := helper.NewActivityModel(thresholds); err != nil {
+		log.Fatal().Err(err).Msgf("ACTIVITY_MODEL %s is not supported", activityModel)
+	}
+	log.Info().Msgf("Checking user activity with the %s model", activityModel)
+	return activityModel
+}
+
+func getSubjectFilter() *helper.SubjectFilter {
+	subjectFilterRulesPath := os.Getenv("SUBJECT_FILTER_RULES_PATH")
+	subjectFilter, err := helper.LoadSubjectFilter(subjectFilterRulesPath)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to load SUBJECT_FILTER_RULES_PATH %s", subjectFilterRulesPath)
+	}
+	if subjectFilterRulesPath == "" {
+		log.Info().Msg("Filtering subjects with the default rules")
+	} else {
+		log.Info().Msgf("Filtering subjects with the rules in %s", subjectFilterRulesPath)
+	}
+	return subjectFilter
+}
+
+func parseVipRuleSet(ruleSetStr string) (model.SubjectType, []string, error) {
+	subjectTypeStr, names, found := strings.Cut(ruleSetStr, "=")
+	if !found {
+		return 0, nil, fmt.Errorf("vip rule set %s should be in the form of subject_type=rule,rule", ruleSetStr)
+	}
+	subjectType, err := model.SubjectTypeFromString(strings.TrimSpace(subjectTypeStr))
+	if err != nil {
+		return 0, nil, err
+	}
+	return subjectType, splitList(names), nil
+}
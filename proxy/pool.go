@@ -0,0 +1,251 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type Assignment int
+
+const (
+	// RoundRobin sends every request through the next proxy
+	RoundRobin Assignment = iota
+	// Sticky sends all requests to a host through the same proxy until the proxy is removed
+	Sticky
+)
+
+func AssignmentFromString(assignmentStr string) (Assignment, error) {
+	switch assignmentStr {
+	case "round_robin":
+		return RoundRobin, nil
+	case "sticky":
+		return Sticky, nil
+	default:
+		return -1, fmt.Errorf("proxy assignment %s is not supported", assignmentStr)
+	}
+}
+
+var ErrNoHealthyProxy = errors.New("no healthy proxy left in the pool")
+
+type proxyState struct {
+	url               *url.URL
+	transport         *http.Transport
+	consecutiveErrors int
+	removed           bool
+	throttles         int       // responses in a row telling the ip of the proxy is rate limited
+	throttledUntil    time.Time // requests through the proxy wait until then
+}
+
+// Pool is an http.RoundTripper spreading requests over a set of proxies
+// A proxy failing maxConsecutiveErrors requests in a row is removed, and is added back once it passes a health check
+// A proxy whose ip is rate limited is not failing, its requests wait for the Retry-After of the response or an exponential backoff instead
+type Pool struct {
+	lock                 sync.Mutex
+	proxies              []*proxyState
+	assignment           Assignment
+	next                 int
+	stickyProxies        map[string]*proxyState // host -> proxy
+	maxConsecutiveErrors int
+	throttleBackoff      time.Duration // backoff after the first rate limited response, doubled on each one in a row
+	maxThrottleBackoff   time.Duration
+	stopHealthCheck      chan struct{}
+}
+
+func NewPool(proxyUrls []string, assignment Assignment, maxConsecutiveErrors int, throttleBackoff, maxThrottleBackoff time.Duration) (*Pool, error) {
+	if len(proxyUrls) == 0 {
+		return nil, errors.New("at least one proxy url is required")
+	}
+
+	proxies := make([]*proxyState, 0, len(proxyUrls))
+	for _, proxyUrl := range proxyUrls {
+		parsedUrl, err := url.Parse(proxyUrl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url %s (%w)", proxyUrl, err)
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = http.ProxyURL(parsedUrl)
+		proxies = append(proxies, &proxyState{
+			url:       parsedUrl,
+			transport: transport,
+		})
+	}
+
+	return &Pool{
+		proxies:              proxies,
+		assignment:           assignment,
+		stickyProxies:        make(map[string]*proxyState),
+		maxConsecutiveErrors: maxConsecutiveErrors,
+		throttleBackoff:      throttleBackoff,
+		maxThrottleBackoff:   maxThrottleBackoff,
+	}, nil
+}
+
+func (pool *Pool) RoundTrip(req *http.Request) (*http.Response, error) {
+	proxy := pool.pick(req.URL.Host)
+	if proxy == nil {
+		return nil, ErrNoHealthyProxy
+	}
+
+	if err := pool.waitThrottle(req, proxy); err != nil {
+		return nil, err
+	}
+
+	resp, err := proxy.transport.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		pool.throttle(proxy, resp.Header.Get("Retry-After"))
+		return resp, err
+	}
+	pool.report(proxy, err == nil && !isProxyError(resp.StatusCode))
+	return resp, err
+}
+
+// waitThrottle waits until the proxy is no longer rate limited, or the request is canceled
+func (pool *Pool) waitThrottle(req *http.Request, proxy *proxyState) error {
+	pool.lock.Lock()
+	wait := time.Until(proxy.throttledUntil)
+	pool.lock.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
+
+// throttle delays the next requests through the proxy by the Retry-After of the response if any, by an exponential backoff otherwise
+func (pool *Pool) throttle(proxy *proxyState, retryAfter string) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	proxy.throttles++
+	backoff := pool.throttleBackoff << min(proxy.throttles-1, 16)
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		backoff = time.Duration(seconds) * time.Second
+	} else if retryAt, err := http.ParseTime(retryAfter); err == nil {
+		backoff = time.Until(retryAt)
+	}
+	backoff = min(backoff, pool.maxThrottleBackoff)
+	if throttledUntil := time.Now().Add(backoff); throttledUntil.After(proxy.throttledUntil) {
+		proxy.throttledUntil = throttledUntil
+	}
+	log.Warn().Msgf("Proxy %s is rate limited %d times in a row. Backing off for %s", proxy.url.Redacted(), proxy.throttles, backoff)
+}
+
+// StartHealthCheck probes every proxy with a GET of checkUrl at the given interval until Stop is called
+func (pool *Pool) StartHealthCheck(checkUrl string, interval time.Duration) {
+	pool.stopHealthCheck = make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				pool.checkHealth(checkUrl)
+			case <-pool.stopHealthCheck:
+				return
+			}
+		}
+	}()
+}
+
+func (pool *Pool) Stop() {
+	if pool.stopHealthCheck != nil {
+		close(pool.stopHealthCheck)
+	}
+}
+
+func (pool *Pool) pick(host string) *proxyState {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	if pool.assignment == Sticky {
+		if proxy, ok := pool.stickyProxies[host]; ok && !proxy.removed {
+			return proxy
+		}
+	}
+	for i := 0; i < len(pool.proxies); i++ {
+		proxy := pool.proxies[pool.next]
+		pool.next = (pool.next + 1) % len(pool.proxies)
+		if !proxy.removed {
+			if pool.assignment == Sticky {
+				pool.stickyProxies[host] = proxy
+			}
+			return proxy
+		}
+	}
+	return nil
+}
+
+func (pool *Pool) report(proxy *proxyState, ok bool) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	if ok {
+		proxy.consecutiveErrors = 0
+		proxy.throttles = 0
+		return
+	}
+	proxy.consecutiveErrors++
+	if !proxy.removed && proxy.consecutiveErrors >= pool.maxConsecutiveErrors {
+		proxy.removed = true
+		log.Warn().Msgf("Proxy %s failed %d requests in a row. Removing it from the pool", proxy.url.Redacted(), proxy.consecutiveErrors)
+	}
+}
+
+func (pool *Pool) checkHealth(checkUrl string) {
+	pool.lock.Lock()
+	proxies := append([]*proxyState(nil), pool.proxies...)
+	pool.lock.Unlock()
+
+	for _, proxy := range proxies {
+		healthy := probe(proxy, checkUrl)
+		pool.lock.Lock()
+		if healthy {
+			if proxy.removed {
+				log.Info().Msgf("Proxy %s passed health check. Adding it back to the pool", proxy.url.Redacted())
+			}
+			proxy.removed = false
+			proxy.consecutiveErrors = 0
+		} else {
+			proxy.consecutiveErrors++
+			if !proxy.removed && proxy.consecutiveErrors >= pool.maxConsecutiveErrors {
+				proxy.removed = true
+				log.Warn().Msgf("Proxy %s failed health check. Removing it from the pool", proxy.url.Redacted())
+			}
+		}
+		pool.lock.Unlock()
+	}
+}
+
+func probe(proxy *proxyState, checkUrl string) bool {
+	client := &http.Client{
+		Transport: proxy.transport,
+		Timeout:   10 * time.Second,
+	}
+	resp, err := client.Get(checkUrl)
+	if err != nil {
+		log.Debug().Err(err).Msgf("Health check of proxy %s failed", proxy.url.Redacted())
+		return false
+	}
+	resp.Body.Close()
+	return !isProxyError(resp.StatusCode)
+}
+
+// isProxyError tells if the response status likely comes from the proxy
+// Rate limited responses come from the target and are backed off instead, see throttle
+func isProxyError(statusCode int) bool {
+	return statusCode == http.StatusProxyAuthRequired || statusCode == http.StatusBadGateway || statusCode == http.StatusGatewayTimeout
+}
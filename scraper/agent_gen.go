@@ -2,6 +2,7 @@ package scraper
 
 import (
 	"math/rand"
+	"sync"
 	"time"
 )
 
@@ -14,6 +15,7 @@ var userAgentList = []string{
 }
 
 type UserAgentGenerator struct {
+	lock    sync.Mutex // rand.Rand is not safe for concurrent use
 	randGen *rand.Rand
 }
 
@@ -24,5 +26,7 @@ func NewUserAgentGenerator() *UserAgentGenerator {
 }
 
 func (agentGen *UserAgentGenerator) RandomUserAgent() string {
+	agentGen.lock.Lock()
+	defer agentGen.lock.Unlock()
 	return userAgentList[agentGen.randGen.Intn(len(userAgentList))]
 }
@@ -0,0 +1,51 @@
+package scraper
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// domainLimiter applies the domain rules to the requests of all collectors of a run together
+// colly limits each collector on its own, so concurrent scrapers would multiply the parallelism of a mirror
+type domainLimiter struct {
+	next  http.RoundTripper
+	slots map[string]chan struct{}
+	rules map[string]DomainRule
+}
+
+func newDomainLimiter(next http.RoundTripper, rules []DomainRule) *domainLimiter {
+	limiter := &domainLimiter{
+		next:  next,
+		slots: make(map[string]chan struct{}, len(rules)),
+		rules: make(map[string]DomainRule, len(rules)),
+	}
+	for _, rule := range rules {
+		limiter.slots[rule.Domain] = make(chan struct{}, max(1, rule.Parallelism))
+		limiter.rules[rule.Domain] = rule
+	}
+	return limiter
+}
+
+// RoundTrip waits for a free slot of the domain, and keeps the slot for the delay of the rule once the response is received
+// Requests to domains without a rule are not limited
+func (limiter *domainLimiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	slots, ok := limiter.slots[req.URL.Host]
+	if !ok {
+		return limiter.next.RoundTrip(req)
+	}
+	select {
+	case slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+
+	resp, err := limiter.next.RoundTrip(req)
+	rule := limiter.rules[req.URL.Host]
+	delay := rule.Delay
+	if rule.RandomDelay > 0 {
+		delay += time.Duration(rand.Int63n(int64(rule.RandomDelay)))
+	}
+	time.AfterFunc(delay, func() { <-slots })
+	return resp, err
+}
@@ -0,0 +1,166 @@
+package scraper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/AlcEccentric/beck-mizuki/util"
+	"github.com/rs/zerolog/log"
+)
+
+// Frontier keeps track of the scraping progress of a cold start so that it can be resumed after a crash
+// It is shared by all scrapers of a run and checkpointed to a json file
+type Frontier struct {
+	lock        sync.Mutex
+	path        string
+	lastSavedAt time.Time
+	dirty       bool
+	state       frontierState
+}
+
+type frontierState struct {
+	PendingPages          map[string]int       `json:"pending_pages"` // subject id -> next page to visit
+	CompletedSubjects     map[string]struct{}  `json:"completed_subjects"`
+	NewestCollectionTimes map[string]time.Time `json:"newest_collection_times"` // subject id -> newest collection time seen
+}
+
+func NewFrontier(path string) *Frontier {
+	return &Frontier{
+		path:        path,
+		lastSavedAt: time.Now(),
+		state: frontierState{
+			PendingPages:          make(map[string]int),
+			CompletedSubjects:     make(map[string]struct{}),
+			NewestCollectionTimes: make(map[string]time.Time),
+		},
+	}
+}
+
+// LoadFrontier restores the frontier from the checkpoint at path, or starts a new one if there is no checkpoint
+func LoadFrontier(path string) (*Frontier, error) {
+	frontier := NewFrontier(path)
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		log.Warn().Msgf("No scraper checkpoint found at %s. Starting from scratch", path)
+		return frontier, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read scraper checkpoint %s (%w)", path, err)
+	}
+
+	if err := json.Unmarshal(content, &frontier.state); err != nil {
+		return nil, fmt.Errorf("failed to parse scraper checkpoint %s (%w)", path, err)
+	}
+	if frontier.state.NewestCollectionTimes == nil {
+		frontier.state.NewestCollectionTimes = make(map[string]time.Time)
+	}
+	log.Info().Msgf("Resuming from scraper checkpoint %s with %d completed subjects and %d pending subjects",
+		path, len(frontier.state.CompletedSubjects), len(frontier.state.PendingPages))
+	return frontier, nil
+}
+
+// NextPage returns the page to start crawling the subject from, and whether the subject was already completed
+func (frontier *Frontier) NextPage(sid string) (int, bool) {
+	frontier.lock.Lock()
+	defer frontier.lock.Unlock()
+
+	if _, ok := frontier.state.CompletedSubjects[sid]; ok {
+		return 0, true
+	}
+	if page, ok := frontier.state.PendingPages[sid]; ok {
+		return page, false
+	}
+	return 1, false
+}
+
+func (frontier *Frontier) SetPendingPage(sid string, page int) {
+	frontier.update(func(state *frontierState) {
+		state.PendingPages[sid] = page
+	})
+}
+
+func (frontier *Frontier) Complete(sid string) {
+	frontier.update(func(state *frontierState) {
+		delete(state.PendingPages, sid)
+		state.CompletedSubjects[sid] = struct{}{}
+	})
+}
+
+func (frontier *Frontier) ObserveCollectionTime(sid string, collectionTime time.Time) {
+	frontier.update(func(state *frontierState) {
+		if newest, ok := state.NewestCollectionTimes[sid]; !ok || collectionTime.After(newest) {
+			state.NewestCollectionTimes[sid] = collectionTime
+		}
+	})
+}
+
+// NewWatermark returns the newest collection time seen on the subject once the subject is completed
+func (frontier *Frontier) NewWatermark(sid string) (time.Time, bool) {
+	frontier.lock.Lock()
+	defer frontier.lock.Unlock()
+
+	if _, ok := frontier.state.CompletedSubjects[sid]; !ok {
+		return time.Time{}, false
+	}
+	newest, ok := frontier.state.NewestCollectionTimes[sid]
+	return newest, ok
+}
+
+func (frontier *Frontier) Save() error {
+	frontier.lock.Lock()
+	defer frontier.lock.Unlock()
+	return frontier.save()
+}
+
+// Remove deletes the checkpoint once the run it belongs to has finished
+func (frontier *Frontier) Remove() error {
+	frontier.lock.Lock()
+	defer frontier.lock.Unlock()
+
+	if err := os.Remove(frontier.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	frontier.dirty = false
+	return nil
+}
+
+// update applies the mutation and checkpoints the frontier if the last checkpoint is old enough
+func (frontier *Frontier) update(mutate func(state *frontierState)) {
+	frontier.lock.Lock()
+	defer frontier.lock.Unlock()
+
+	mutate(&frontier.state)
+	frontier.dirty = true
+	if time.Since(frontier.lastSavedAt) >= util.ScraperCheckpointIntervalInS*time.Second {
+		if err := frontier.save(); err != nil {
+			log.Error().Err(err).Msgf("Failed to save scraper checkpoint %s", frontier.path)
+		}
+	}
+}
+
+func (frontier *Frontier) save() error {
+	if !frontier.dirty {
+		return nil
+	}
+
+	content, err := json.Marshal(frontier.state)
+	if err != nil {
+		return err
+	}
+	// write to a temp file first so that a crash while saving does not corrupt the last checkpoint
+	tmpPath := frontier.path + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, frontier.path); err != nil {
+		return err
+	}
+
+	frontier.lastSavedAt = time.Now()
+	frontier.dirty = false
+	log.Debug().Msgf("Saved scraper checkpoint %s", frontier.path)
+	return nil
+}
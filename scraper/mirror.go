@@ -0,0 +1,69 @@
+package scraper
+
+import (
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// MirrorPool tracks which mirror of the website is scraped, and fails over to the next mirror
+// once the current one returns failoverThreshold errors in a row
+type MirrorPool struct {
+	lock              sync.Mutex
+	domains           []string
+	current           int
+	consecutiveErrors int
+	failoverThreshold int
+}
+
+func NewMirrorPool(domains []string, failoverThreshold int) *MirrorPool {
+	if len(domains) == 0 {
+		log.Fatal().Msg("At least one mirror domain is required")
+	}
+	return &MirrorPool{
+		domains:           domains,
+		failoverThreshold: failoverThreshold,
+	}
+}
+
+func (pool *MirrorPool) Domains() []string {
+	return pool.domains
+}
+
+// URL returns the address of the path (including the query) on the current mirror
+func (pool *MirrorPool) URL(path string) string {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	return "https://" + pool.domains[pool.current] + path
+}
+
+func (pool *MirrorPool) IsCurrent(domain string) bool {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	return pool.domains[pool.current] == domain
+}
+
+func (pool *MirrorPool) ReportSuccess(domain string) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	if pool.domains[pool.current] == domain {
+		pool.consecutiveErrors = 0
+	}
+}
+
+// ReportFailure counts an error of the domain and fails over to the next mirror if the threshold is reached
+// Errors of domains that were already failed over are ignored
+func (pool *MirrorPool) ReportFailure(domain string) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	if pool.domains[pool.current] != domain {
+		return
+	}
+
+	pool.consecutiveErrors++
+	if pool.consecutiveErrors >= pool.failoverThreshold && len(pool.domains) > 1 {
+		pool.current = (pool.current + 1) % len(pool.domains)
+		pool.consecutiveErrors = 0
+		log.Warn().Msgf("Mirror %s returned %d errors in a row. Failing over to %s", domain, pool.failoverThreshold, pool.domains[pool.current])
+	}
+}
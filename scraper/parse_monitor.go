@@ -0,0 +1,73 @@
+package scraper
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ParseMonitor counts the pages that failed to parse during a run
+// Once the failure rate crosses the threshold the run should be aborted, as the website layout has likely changed
+type ParseMonitor struct {
+	lock                 sync.Mutex
+	parsedPages          int
+	failedPages          int
+	failureRateThreshold float64
+	minPages             int // pages to see before the failure rate is trusted
+	lastErr              error
+	abortErr             error // set once the run must be aborted whatever the failure rate
+}
+
+func NewParseMonitor(failureRateThreshold float64, minPages int) *ParseMonitor {
+	return &ParseMonitor{
+		failureRateThreshold: failureRateThreshold,
+		minPages:             minPages,
+	}
+}
+
+func (monitor *ParseMonitor) Record(err error) {
+	monitor.lock.Lock()
+	defer monitor.lock.Unlock()
+
+	monitor.parsedPages++
+	if err != nil {
+		monitor.failedPages++
+		monitor.lastErr = err
+	}
+}
+
+// Abort makes Err return the error, e.g. once a subject could not be parsed at all
+func (monitor *ParseMonitor) Abort(err error) {
+	monitor.lock.Lock()
+	defer monitor.lock.Unlock()
+
+	if monitor.abortErr == nil {
+		monitor.abortErr = err
+	}
+}
+
+// Err returns a non nil error once the failure rate crossed the threshold or the run was aborted
+func (monitor *ParseMonitor) Err() error {
+	monitor.lock.Lock()
+	defer monitor.lock.Unlock()
+
+	if monitor.abortErr != nil {
+		return monitor.abortErr
+	}
+	if monitor.parsedPages < monitor.minPages {
+		return nil
+	}
+	failureRate := float64(monitor.failedPages) / float64(monitor.parsedPages)
+	if failureRate <= monitor.failureRateThreshold {
+		return nil
+	}
+	return fmt.Errorf("%d of %d scraped pages failed to parse (%.0f%% > %.0f%%), last error: %w",
+		monitor.failedPages, monitor.parsedPages, failureRate*100, monitor.failureRateThreshold*100, monitor.lastErr)
+}
+
+func (monitor *ParseMonitor) LogSummary() {
+	monitor.lock.Lock()
+	defer monitor.lock.Unlock()
+	log.Info().Msgf("%d of %d scraped pages failed to parse", monitor.failedPages, monitor.parsedPages)
+}
@@ -0,0 +1,116 @@
+package scraper
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/AlcEccentric/beck-mizuki/util"
+	"github.com/gocolly/colly"
+	"github.com/rs/zerolog/log"
+)
+
+const mirrorAttemptsCtxKey = "mirrorAttempts"
+
+// DomainRule is the politeness rule applied to requests to one mirror, shared by all collectors of a run
+// Every request waits Delay plus a random duration up to RandomDelay
+type DomainRule struct {
+	Domain      string
+	Parallelism int
+	Delay       time.Duration
+	RandomDelay time.Duration
+}
+
+func DefaultDomainRule(domain string) DomainRule {
+	return DomainRule{
+		Domain:      domain,
+		Parallelism: 1,
+		Delay:       util.ScraperBaseDelayInS * time.Second,
+		RandomDelay: util.ScraperAdditionalDelayInS * time.Second,
+	}
+}
+
+// ScrapingPolicy is shared by all collectors of a run
+type ScrapingPolicy struct {
+	Mirrors          *MirrorPool
+	DomainRules      map[string]DomainRule // rules of mirrors not listed fall back to DefaultDomainRule
+	RespectRobotsTxt bool
+	Transport        http.RoundTripper // nil to use the default transport
+	// parser of subject user list pages, see GetSubjectPageParser
+	SubjectPageParser SubjectPageParser
+	// the scraping horizon is counted in calendar days of this zone
+	CalendarLocation *time.Location
+
+	limiterOnce sync.Once
+	limiter     *domainLimiter
+}
+
+func (policy *ScrapingPolicy) domainRule(domain string) DomainRule {
+	if rule, ok := policy.DomainRules[domain]; ok {
+		return rule
+	}
+	return DefaultDomainRule(domain)
+}
+
+// domainLimiter returns the transport limiting the requests of all collectors to each mirror
+func (policy *ScrapingPolicy) domainLimiter() *domainLimiter {
+	policy.limiterOnce.Do(func() {
+		transport := policy.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		rules := make([]DomainRule, 0, len(policy.Mirrors.Domains()))
+		for _, domain := range policy.Mirrors.Domains() {
+			rules = append(rules, policy.domainRule(domain))
+		}
+		policy.limiter = newDomainLimiter(transport, rules)
+	})
+	return policy.limiter
+}
+
+// newCollector creates an async collector following the policy
+// Its requests get a random user agent each, and failed requests are retried or moved to another mirror
+func (policy *ScrapingPolicy) newCollector() *colly.Collector {
+	collector := colly.NewCollector(
+		colly.Async(true),
+	)
+	collector.IgnoreRobotsTxt = !policy.RespectRobotsTxt
+	collector.WithTransport(policy.domainLimiter())
+
+	agentGen := NewUserAgentGenerator()
+	collector.OnRequest(func(r *colly.Request) {
+		r.Headers.Set("User-Agent", agentGen.RandomUserAgent())
+	})
+	collector.OnResponse(func(r *colly.Response) {
+		policy.Mirrors.ReportSuccess(r.Request.URL.Host)
+	})
+	collector.OnError(func(r *colly.Response, err error) {
+		policy.handleError(collector, r, err)
+	})
+	return collector
+}
+
+// handleError moves the request to the current mirror if its mirror was failed over, otherwise retries it
+func (policy *ScrapingPolicy) handleError(collector *colly.Collector, r *colly.Response, err error) {
+	domain := r.Request.URL.Host
+	if isMirrorError(r.StatusCode) {
+		policy.Mirrors.ReportFailure(domain)
+	}
+
+	attempts, _ := r.Request.Ctx.GetAny(mirrorAttemptsCtxKey).(int)
+	if !policy.Mirrors.IsCurrent(domain) && attempts < len(policy.Mirrors.Domains()) {
+		mirroredAddr := policy.Mirrors.URL(r.Request.URL.RequestURI())
+		log.Warn().Err(err).Msgf("Failed to visit %s. Retrying on %s", r.Request.URL.String(), mirroredAddr)
+		r.Request.Ctx.Put(mirrorAttemptsCtxKey, attempts+1)
+		if err := collector.Request("GET", mirroredAddr, nil, r.Request.Ctx, nil); err != nil {
+			log.Error().Err(err).Msgf("Failed to retry on mirror. Skipping %s", mirroredAddr)
+		}
+		return
+	}
+	handleRetryableError(r, err)
+}
+
+// isMirrorError tells if the error is likely caused by the mirror rather than by the requested page
+func isMirrorError(statusCode int) bool {
+	return statusCode == 0 || statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
@@ -0,0 +1,51 @@
+package scraper
+
+import (
+	"fmt"
+
+	"github.com/AlcEccentric/beck-mizuki/model"
+	"github.com/AlcEccentric/beck-mizuki/util"
+)
+
+// SubjectPageKind is a paginated list of users on a subject page, all sharing the same li.user layout
+type SubjectPageKind struct {
+	Name       string
+	PathFormat string
+	// collection type of the listed users when the page does not show it
+	DefaultCollectionType model.CollectionType
+}
+
+var (
+	SubjectCollectionsPage = SubjectPageKind{
+		Name:       "collections",
+		PathFormat: util.SubjectCollectionPathFormat,
+	}
+	SubjectDoingsPage = SubjectPageKind{
+		Name:                  "doings",
+		PathFormat:            util.SubjectDoingsPathFormat,
+		DefaultCollectionType: model.Watching,
+	}
+	SubjectWishesPage = SubjectPageKind{
+		Name:                  "wishes",
+		PathFormat:            util.SubjectWishesPathFormat,
+		DefaultCollectionType: model.ToWatch,
+	}
+)
+
+func SubjectPageKindFromString(kindStr string) (SubjectPageKind, error) {
+	for _, kind := range []SubjectPageKind{SubjectCollectionsPage, SubjectDoingsPage, SubjectWishesPage} {
+		if kind.Name == kindStr {
+			return kind, nil
+		}
+	}
+	return SubjectPageKind{}, fmt.Errorf("subject page kind %s is not supported", kindStr)
+}
+
+// FrontierKey identifies the subject page in the frontier
+// Collections pages are keyed by the bare subject id, and watermarks are stored under the same keys
+func (kind SubjectPageKind) FrontierKey(sid string) string {
+	if kind.Name == SubjectCollectionsPage.Name {
+		return sid
+	}
+	return kind.Name + "/" + sid
+}
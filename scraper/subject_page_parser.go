@@ -0,0 +1,218 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/AlcEccentric/beck-mizuki/model"
+	"github.com/AlcEccentric/beck-mizuki/util"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrLayoutChanged is returned when a page does not have the structure the parser expects
+var ErrLayoutChanged = errors.New("page layout does not match the parser")
+
+// SubjectPage is the content of one page of a subject user list (collections, doings or wishes)
+type SubjectPage struct {
+	CurIndex int                `json:"cur_index"`
+	MaxIndex int                `json:"max_index"`
+	Entries  []SubjectPageEntry `json:"entries"`
+}
+
+type SubjectPageEntry struct {
+	Uid            string               `json:"uid"`
+	CollectedTime  time.Time            `json:"collected_time"`
+	CollectionType model.CollectionType `json:"collection_type"` // 0 when the status is not shown
+	Rating         int64                `json:"rating"`          // 0 when not rated
+	Comment        string               `json:"comment"`
+}
+
+// SubjectPageParser parses and validates the main wrapper of a subject user list page
+// Parsers are versioned so that a new website layout gets a new parser instead of patching the running one
+type SubjectPageParser interface {
+	Version() string
+	Parse(mainWrapper *goquery.Selection, pageUrl *url.URL) (SubjectPage, error)
+}
+
+var subjectPageParsers = map[string]SubjectPageParser{
+	"v1": subjectPageParserV1{},
+}
+
+func GetSubjectPageParser(version string) (SubjectPageParser, error) {
+	if parser, ok := subjectPageParsers[version]; ok {
+		return parser, nil
+	}
+	return nil, fmt.Errorf("subject page parser %s is not supported", version)
+}
+
+const (
+	userListSelectorV1         = "ul#memberUserList"
+	userSelectorV1             = "li.user"
+	collectionTimeSelectorV1   = "p.info"
+	ratingSelectorV1           = "span.starlight"
+	commentSelectorV1          = "p.comment"
+	collectionStatusSelectorV1 = "span.tip_j"
+	pageEdgeSelectorV1         = "span.p_edge"
+	pageAnchorSelectorV1       = "div.page_inner a.p"
+)
+
+var ratingClassPattern = regexp.MustCompile(`stars(\d+)`)
+
+// subjectPageParserV1 parses the layout in use since the scraper was written
+type subjectPageParserV1 struct{}
+
+func (parser subjectPageParserV1) Version() string {
+	return "v1"
+}
+
+func (parser subjectPageParserV1) Parse(mainWrapper *goquery.Selection, pageUrl *url.URL) (SubjectPage, error) {
+	if mainWrapper.Find(userListSelectorV1).Length() == 0 {
+		return SubjectPage{}, fmt.Errorf("%w: %s not found on %s", ErrLayoutChanged, userListSelectorV1, pageUrl)
+	}
+
+	curIndex, err := getCurIndex(pageUrl)
+	if err != nil {
+		return SubjectPage{}, fmt.Errorf("invalid page index of %s (%w)", pageUrl, err)
+	}
+	maxIndex, err := getMaxIndex(mainWrapper)
+	if err != nil {
+		return SubjectPage{}, fmt.Errorf("%w: %s", ErrLayoutChanged, err)
+	}
+	if curIndex > maxIndex {
+		return SubjectPage{}, fmt.Errorf("%w: page %d is beyond the last page %d on %s", ErrLayoutChanged, curIndex, maxIndex, pageUrl)
+	}
+
+	page := SubjectPage{
+		CurIndex: curIndex,
+		MaxIndex: maxIndex,
+		Entries:  make([]SubjectPageEntry, 0),
+	}
+	users := mainWrapper.Find(userListSelectorV1 + " " + userSelectorV1)
+	failedCnt := 0
+	users.Each(func(_ int, user *goquery.Selection) {
+		entry, err := parser.parseEntry(user)
+		if err != nil {
+			failedCnt++
+			log.Debug().Err(err).Msgf("Failed to parse a user on %s. Skipping...", pageUrl)
+			return
+		}
+		page.Entries = append(page.Entries, entry)
+	})
+
+	// a few malformed users are tolerated, most users failing means the layout changed
+	if failedCnt*2 > users.Length() {
+		return SubjectPage{}, fmt.Errorf("%w: %d of %d users could not be parsed on %s", ErrLayoutChanged, failedCnt, users.Length(), pageUrl)
+	}
+	if users.Length() == 0 && curIndex < maxIndex {
+		return SubjectPage{}, fmt.Errorf("%w: no user found on page %d of %d on %s", ErrLayoutChanged, curIndex, maxIndex, pageUrl)
+	}
+	return page, nil
+}
+
+func (parser subjectPageParserV1) parseEntry(user *goquery.Selection) (SubjectPageEntry, error) {
+	uid, _ := user.Attr("data-item-user")
+	if uid == "" {
+		return SubjectPageEntry{}, errors.New("user id not found")
+	}
+
+	timeContent := strings.TrimSpace(user.Find(collectionTimeSelectorV1).Text())
+	collectedTime, err := time.ParseInLocation(util.WebsiteCollectionTimeFormat, strings.TrimSpace(replaceNonASCIIWithSpaces(timeContent)), util.SiteLocation)
+	if err != nil {
+		return SubjectPageEntry{}, fmt.Errorf("invalid collection time: %s of user %s (%w)", timeContent, uid, err)
+	}
+
+	return SubjectPageEntry{
+		Uid:            uid,
+		CollectedTime:  collectedTime.UTC(),
+		CollectionType: getCollectionType(strings.TrimSpace(user.Find(collectionStatusSelectorV1).Text())),
+		Rating:         getRating(user.Find(ratingSelectorV1).AttrOr("class", "")),
+		Comment:        strings.TrimSpace(user.Find(commentSelectorV1).Text()),
+	}, nil
+}
+
+// getRating reads the rating from the star class (e.g. "starlight stars8"), 0 if the collection is not rated
+func getRating(starClass string) int64 {
+	match := ratingClassPattern.FindStringSubmatch(starClass)
+	if len(match) < 2 {
+		return 0
+	}
+	rating, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return rating
+}
+
+// getCollectionType reads the collection status label, whose verb depends on the subject type (e.g. 看过, 读过, 玩过)
+func getCollectionType(status string) model.CollectionType {
+	switch {
+	case strings.Contains(status, "搁置"):
+		return model.Postponed
+	case strings.Contains(status, "抛弃"):
+		return model.Discarded
+	case strings.HasPrefix(status, "想"):
+		return model.ToWatch
+	case strings.HasPrefix(status, "在"):
+		return model.Watching
+	case strings.HasSuffix(status, "过"):
+		return model.Watched
+	default:
+		return 0
+	}
+}
+
+func getCurIndex(pageUrl *url.URL) (int, error) {
+	curIndexStr := pageUrl.Query().Get("page")
+	return strconv.Atoi(curIndexStr)
+}
+
+func getMaxIndex(page *goquery.Selection) (int, error) {
+	pEdgeContent := strings.TrimSpace(replaceNonASCIIWithSpaces(page.Find(pageEdgeSelectorV1).Text()))
+
+	// When p_edge is empty, it means the # of pages is limited
+	// Max index can be obtained by iterating through the page anchors
+	if pEdgeContent == "" {
+		maxIndex := 1
+		page.Find(pageAnchorSelectorV1).Each(func(_ int, pageAnchor *goquery.Selection) {
+			anchorText := pageAnchor.Text()
+			if anchorText == "" || !unicode.IsDigit([]rune(anchorText)[0]) {
+				return
+			}
+
+			if anchorIndex, err := strconv.Atoi(anchorText); err != nil {
+				log.Error().Err(err).Msgf("Invalid page anchor: %s when scraping user list page. Ignoring...", anchorText)
+			} else {
+				if anchorIndex > maxIndex {
+					maxIndex = anchorIndex
+				}
+			}
+		})
+		return maxIndex, nil
+	} else {
+		edgeParts := strings.Split(pEdgeContent, "/")
+		if len(edgeParts) != 2 {
+			return 0, fmt.Errorf("invalid p_edge: %s when scraping user list page", pEdgeContent)
+		}
+		if maxIndex, err := strconv.Atoi(strings.Trim(edgeParts[1], " )")); err != nil {
+			return 0, fmt.Errorf("invalid p_edge: %s error: %s when scraping user list page", pEdgeContent, err)
+		} else {
+			return maxIndex, nil
+		}
+	}
+}
+
+func replaceNonASCIIWithSpaces(input string) string {
+	return strings.Map(func(r rune) rune {
+		if r > 127 {
+			return ' '
+		}
+		return r
+	}, input)
+}
@@ -0,0 +1,72 @@
+package scraper
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// golden pages of each parser version are testdata/<version>/<name>.html, with the expected result in <name>.json
+type subjectPageGolden struct {
+	Url  string      `json:"url"`
+	Page SubjectPage `json:"page"`
+}
+
+func TestSubjectPageParsersOnGoldenPages(t *testing.T) {
+	for version, parser := range subjectPageParsers {
+		htmlFiles, err := filepath.Glob(filepath.Join("testdata", version, "*.html"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(htmlFiles) == 0 {
+			t.Errorf("no golden page found for parser %s", version)
+		}
+		for _, htmlFile := range htmlFiles {
+			name := strings.TrimSuffix(filepath.Base(htmlFile), ".html")
+			t.Run(version+"/"+name, func(t *testing.T) {
+				checkGoldenPage(t, parser, strings.TrimSuffix(htmlFile, ".html"))
+			})
+		}
+	}
+}
+
+func checkGoldenPage(t *testing.T, parser SubjectPageParser, goldenPath string) {
+	html, err := os.ReadFile(goldenPath + ".html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	goldenContent, err := os.ReadFile(goldenPath + ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var golden subjectPageGolden
+	if err := json.Unmarshal(goldenContent, &golden); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(html))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pageUrl, err := url.Parse(golden.Url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, err := parser.Parse(doc.Find("div.mainWrapper"), pageUrl)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	// compare the json forms so that time zones and empty slices compare the same way as in the golden file
+	parsed, _ := json.Marshal(page)
+	expected, _ := json.Marshal(golden.Page)
+	if !bytes.Equal(parsed, expected) {
+		t.Errorf("got %s, expected %s", parsed, expected)
+	}
+}
@@ -2,13 +2,11 @@ package scraper
 
 import (
 	"fmt"
-	"math/rand"
 	"net/http"
-	"strconv"
-	"strings"
+	"sync"
 	"time"
-	"unicode"
 
+	"github.com/AlcEccentric/beck-mizuki/model"
 	"github.com/AlcEccentric/beck-mizuki/util"
 	"github.com/cenkalti/backoff/v4"
 	"github.com/gocolly/colly"
@@ -16,69 +14,93 @@ import (
 )
 
 type SubjectUserScraper struct {
+	kind               SubjectPageKind
+	policy             *ScrapingPolicy
+	monitor            *ParseMonitor
 	collector          *colly.Collector
 	oldestAccpetedTime time.Time
-	uidChan            chan string
-}
-
-func NewSubjectUserScraper(coldStartIntervalInDays, uidChanSize int) *SubjectUserScraper {
+	sightingChan       chan model.CandidateSighting
+	frontier           *Frontier
+	// newest collection time seen on each subject in the last cold start, pages older than it were already scraped
+	watermarks map[string]time.Time
+	// called with the full collection records of each page, nil if records are not harvested
+	collectionsHandler func([]model.ScrapedCollection)
+	// ids of the requests whose response had the expected structure, to tell the others apart once scraped
+	structuredRequests sync.Map
+	lock               sync.Mutex
+	parsedPages        map[string]int // frontier key -> pages parsed in this run
+}
+
+func NewSubjectUserScraper(policy *ScrapingPolicy, monitor *ParseMonitor, kind SubjectPageKind, coldStartIntervalInDays, sightingChanSize int, frontier *Frontier, watermarks map[string]time.Time,
+	collectionsHandler func([]model.ScrapedCollection)) *SubjectUserScraper {
 	subjectUserScraper := &SubjectUserScraper{
-		collector:          initColly(),
-		oldestAccpetedTime: time.Now().AddDate(0, 0, -coldStartIntervalInDays),
-		uidChan:            make(chan string, uidChanSize),
+		kind:               kind,
+		policy:             policy,
+		monitor:            monitor,
+		collector:          policy.newCollector(),
+		oldestAccpetedTime: util.CalendarWindowStart(time.Now(), coldStartIntervalInDays, policy.CalendarLocation),
+		sightingChan:       make(chan model.CandidateSighting, sightingChanSize),
+		frontier:           frontier,
+		watermarks:         watermarks,
+		collectionsHandler: collectionsHandler,
+		parsedPages:        make(map[string]int),
 	}
 	subjectUserScraper.registerHandler()
 	return subjectUserScraper
 }
 
-func initColly() *colly.Collector {
-	agentGen := NewUserAgentGenerator()
-	collector := colly.NewCollector(
-		colly.UserAgent(agentGen.RandomUserAgent()),
-		colly.Async(true),
-	)
-
-	randGen := rand.New(rand.NewSource(time.Now().UnixNano()))
-	collector.Limit(&colly.LimitRule{
-		DomainGlob:  "*",
-		Parallelism: 1,
-		RandomDelay: time.Duration((randGen.Intn(util.ScraperAdditionalDelayInS) + util.ScraperBaseDelayInS)) * time.Second,
-	})
-	return collector
-}
+// Crawl scrapes the subject from where the frontier left it, and returns false if the subject was already completed
+func (scraper *SubjectUserScraper) Crawl(sid string) bool {
+	if scraper.monitor.Err() != nil {
+		return false
+	}
+	page, completed := scraper.frontier.NextPage(scraper.kind.FrontierKey(sid))
+	if completed {
+		log.Debug().Msgf("Subject %s %s page was completed before. Skipping", sid, scraper.kind.Name)
+		return false
+	}
 
-func (scraper *SubjectUserScraper) Crawl(sid string) {
 	ctx := colly.NewContext()
 	ctx.Put("subjectId", sid)
 
-	scraper.collector.Request("GET", fmt.Sprintf(util.SubjectCollectionUrlFormat, sid, 1), nil, ctx, nil)
+	scraper.collector.Request("GET", scraper.policy.Mirrors.URL(fmt.Sprintf(scraper.kind.PathFormat, sid, page)), nil, ctx, nil)
 	scraper.collector.Wait()
-}
 
-func (scraper *SubjectUserScraper) CloseUidChan() {
-	close(scraper.uidChan)
+	// a subject without any parsed page means the pages are no longer what the parser expects, e.g. a captcha or login page
+	scraper.lock.Lock()
+	parsedPages := scraper.parsedPages[scraper.kind.FrontierKey(sid)]
+	scraper.lock.Unlock()
+	if parsedPages == 0 {
+		scraper.monitor.Abort(fmt.Errorf("no %s page of subject %s was parsed", scraper.kind.Name, sid))
+	}
+	return true
 }
 
-func (scraper *SubjectUserScraper) CollectUids() []string {
-	uidSet := make(map[string]struct{})
-	for uid := range scraper.uidChan {
-		uidSet[uid] = struct{}{}
-	}
+func (scraper *SubjectUserScraper) CloseSightingChan() {
+	close(scraper.sightingChan)
+}
 
-	uidSlice := make([]string, 0, len(uidSet))
-	for uid := range uidSet {
-		uidSlice = append(uidSlice, uid)
-	}
-	return uidSlice
+// CollectSightings drains the sightings until the channel is closed
+func (scraper *SubjectUserScraper) CollectSightings() []model.CandidateSighting {
+	return collectSightings(scraper.sightingChan)
 }
 
 func (scraper *SubjectUserScraper) registerHandler() {
 	scraper.collector.OnHTML("div.mainWrapper", scraper.handleMainWrapper)
+	scraper.collector.OnScraped(scraper.handleScraped)
+}
 
-	scraper.collector.OnError(scraper.handleRetryableError)
+// handleScraped records every response without the main wrapper as a parse failure, as the parser never sees them
+func (scraper *SubjectUserScraper) handleScraped(r *colly.Response) {
+	if _, structured := scraper.structuredRequests.LoadAndDelete(r.Request.ID); structured {
+		return
+	}
+	err := fmt.Errorf("page %s has no div.mainWrapper", r.Request.URL.String())
+	scraper.monitor.Record(err)
+	log.Error().Err(err).Msg("Failed to parse page")
 }
 
-func (scraper *SubjectUserScraper) handleRetryableError(r *colly.Response, err error) {
+func handleRetryableError(r *colly.Response, err error) {
 	shouldRetry := func(statusCode int) bool {
 		switch statusCode {
 		case http.StatusInternalServerError, http.StatusBadGateway,
@@ -107,116 +129,97 @@ func (scraper *SubjectUserScraper) handleRetryableError(r *colly.Response, err e
 
 func (scraper *SubjectUserScraper) handleMainWrapper(page *colly.HTMLElement) {
 	log.Debug().Msgf("SubjectUserScraper processing page %s", page.Request.URL.String())
-	maxIndex, err := scraper.getMaxIndex(page)
-	if err != nil {
-		log.Error().Err(err).Msgf("Failed to get max index. Skipping %s", page.Request.URL.String())
+	scraper.structuredRequests.Store(page.Request.ID, struct{}{})
+	if scraper.monitor.Err() != nil {
+		// the run is being aborted, no need to go further
 		return
 	}
 
 	sid := page.Request.Ctx.Get("subjectId")
 	if sid == "" {
-		log.Error().Err(err).Msgf("Subject id not found in context. Skipping %s", page.Request.URL.String())
+		log.Error().Msgf("Subject id not found in context. Skipping %s", page.Request.URL.String())
+		return
+	}
+
+	subjectPage, err := scraper.policy.SubjectPageParser.Parse(page.DOM, page.Request.URL)
+	scraper.monitor.Record(err)
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to parse page. Skipping %s", page.Request.URL.String())
 		return
 	}
+	scraper.lock.Lock()
+	scraper.parsedPages[scraper.kind.FrontierKey(sid)]++
+	scraper.lock.Unlock()
 
-	beyondTimeHorizon := scraper.processUserCollections(page, sid)
+	beyondTimeHorizon := scraper.processUserCollections(subjectPage.Entries, sid, page.Request.URL.String())
 	if !beyondTimeHorizon {
-		scraper.checkAndVisitNextPage(page, sid, maxIndex)
+		scraper.checkAndVisitNextPage(page, sid, subjectPage)
 	} else {
 		log.Debug().Msgf("Wont check next page and stop at %s", page.Request.URL.String())
+		scraper.frontier.Complete(scraper.kind.FrontierKey(sid))
 	}
 }
 
-func (scraper *SubjectUserScraper) processUserCollections(page *colly.HTMLElement, sid string) bool {
+func (scraper *SubjectUserScraper) processUserCollections(entries []SubjectPageEntry, sid string, pageAddr string) bool {
 	beyondTimeHorizon := false
-	page.ForEachWithBreak("li.user", func(_ int, col *colly.HTMLElement) bool {
-		uid := col.Attr("data-item-user")
-		collectionTime, err := scraper.getCollectionTime(col)
-
-		if err != nil {
-			log.Error().Err(err).Msgf("Failed to get collection time for uid: %s when visiting %s. Skipping...", uid, page.Request.URL.String())
-			return true // skip this and continue
+	scrapedCollections := make([]model.ScrapedCollection, 0)
+	defer func() {
+		if scraper.collectionsHandler != nil && len(scrapedCollections) > 0 {
+			scraper.collectionsHandler(scrapedCollections)
 		}
+	}()
 
-		if scraper.isBeyondTimeHorizon(collectionTime) {
-			log.Debug().Msgf("Collection time %s for uid: %s for subject id: %s is beyond time horizon. Stop looking further", collectionTime, uid, sid)
+	for _, entry := range entries {
+		if scraper.isBeyondTimeHorizon(sid, entry.CollectedTime) {
+			log.Debug().Msgf("Collection time %s for uid: %s for subject id: %s is beyond time horizon. Stop looking further at %s",
+				entry.CollectedTime, entry.Uid, sid, pageAddr)
 			beyondTimeHorizon = true
-			return false
-		} else {
-			scraper.uidChan <- uid
-			return true // skip this and continue
+			break
 		}
-	})
-	return beyondTimeHorizon
-}
 
-func (scraper *SubjectUserScraper) checkAndVisitNextPage(page *colly.HTMLElement, sid string, maxIndex int) {
-	curIndex, err := scraper.getCurIndex(page)
-	if err != nil {
-		log.Error().Err(err).Msgf("Failed to get current index. Skipping %s", page.Request.URL.String())
-		return
+		scraper.frontier.ObserveCollectionTime(scraper.kind.FrontierKey(sid), entry.CollectedTime)
+		if scraper.collectionsHandler != nil {
+			collectionType := entry.CollectionType
+			if collectionType == 0 {
+				collectionType = scraper.kind.DefaultCollectionType
+			}
+			scrapedCollections = append(scrapedCollections, model.ScrapedCollection{
+				UserID:            entry.Uid,
+				SubjectID:         sid,
+				CollectionType:    collectionType,
+				CollectedTime:     entry.CollectedTime,
+				CollectedTimeZone: util.SiteTimeZone,
+				Rating:            entry.Rating,
+				Comment:           entry.Comment,
+				ScrapedAt:         time.Now(),
+			})
+		}
+		scraper.sightingChan <- model.CandidateSighting{
+			UserID:    entry.Uid,
+			Source:    scraper.kind.FrontierKey(sid),
+			SubjectID: sid,
+			SightedAt: entry.CollectedTime,
+		}
 	}
+	return beyondTimeHorizon
+}
 
-	if curIndex < maxIndex {
-		nextPageAddr := fmt.Sprintf(util.SubjectCollectionUrlFormat, sid, curIndex+1)
+func (scraper *SubjectUserScraper) checkAndVisitNextPage(page *colly.HTMLElement, sid string, subjectPage SubjectPage) {
+	curIndex := subjectPage.CurIndex
+	if curIndex < subjectPage.MaxIndex {
+		nextPageAddr := scraper.policy.Mirrors.URL(fmt.Sprintf(scraper.kind.PathFormat, sid, curIndex+1))
 		log.Debug().Msgf("Visiting next page %s", nextPageAddr)
+		scraper.frontier.SetPendingPage(scraper.kind.FrontierKey(sid), curIndex+1)
 		page.Request.Visit(nextPageAddr)
-	}
-}
-
-func (scraper *SubjectUserScraper) isBeyondTimeHorizon(inTime time.Time) bool {
-	return inTime.Before(scraper.oldestAccpetedTime)
-}
-
-func (scraper *SubjectUserScraper) getCollectionTime(collection *colly.HTMLElement) (time.Time, error) {
-	pInfoContent := collection.ChildText("p.info")
-	if parsedTime, err := time.Parse(util.WebsiteCollectionTimeFormat, replaceNonASCIIWithSpaces(pInfoContent)); err != nil {
-		return time.Now(), fmt.Errorf("invalid collection time: %s error: %s when scraping user id from subject page", pInfoContent, err)
 	} else {
-		return parsedTime, nil
+		scraper.frontier.Complete(scraper.kind.FrontierKey(sid))
 	}
 }
 
-func (scraper *SubjectUserScraper) getCurIndex(page *colly.HTMLElement) (int, error) {
-	curIndexStr := page.Request.URL.Query().Get("page")
-	return strconv.Atoi(curIndexStr)
-}
-
-func (scraper *SubjectUserScraper) getMaxIndex(page *colly.HTMLElement) (int, error) {
-	pEdgeContent := replaceNonASCIIWithSpaces(page.ChildText("span.p_edge"))
-
-	// When p_edge is empty, it means the # of pages is limited
-	// Max index can be obtained by iterating through the page anchors
-	if pEdgeContent == "" {
-		maxIndex := 1
-		page.ForEach("div.page_inner a.p", func(_ int, pageAnchor *colly.HTMLElement) {
-			if !unicode.IsDigit([]rune(pageAnchor.Text)[0]) {
-				return
-			}
-
-			if anchorIndex, err := strconv.Atoi(pageAnchor.Text); err != nil {
-				log.Error().Err(err).Msgf("Invalid page anchor: %s when scraping user id from subject page. Ignoring...", pageAnchor.Text)
-			} else {
-				if anchorIndex > maxIndex {
-					maxIndex = anchorIndex
-				}
-			}
-		})
-		return maxIndex, nil
-	} else {
-		if maxIndex, err := strconv.Atoi(strings.Trim(strings.Split(pEdgeContent, "/")[1], " )")); err != nil {
-			return 0, fmt.Errorf("invalid p_edge: %s error: %s when scraping user id from subject page", pEdgeContent, err)
-		} else {
-			return maxIndex, nil
-		}
+// isBeyondTimeHorizon tells if the collection is older than the cold start interval (in calendar days) or than the subject watermark
+func (scraper *SubjectUserScraper) isBeyondTimeHorizon(sid string, inTime time.Time) bool {
+	if watermark, ok := scraper.watermarks[scraper.kind.FrontierKey(sid)]; ok && inTime.Before(watermark) {
+		return true
 	}
-}
-
-func replaceNonASCIIWithSpaces(input string) string {
-	return strings.Map(func(r rune) rune {
-		if r > 127 {
-			return ' '
-		}
-		return r
-	}, input)
+	return inTime.Before(scraper.oldestAccpetedTime)
 }
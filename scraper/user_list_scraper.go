@@ -0,0 +1,132 @@
+package scraper
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/AlcEccentric/beck-mizuki/model"
+
+	"github.com/gocolly/colly"
+	"github.com/rs/zerolog/log"
+)
+
+const userListAvatarSelector = "li.user a.avatar"
+
+// UserListScraper scrapes the uids listed on paginated user lists, e.g. group members or user friends
+// Unlike subject pages, user lists are not ordered by time, so every page of a list is scraped
+type UserListScraper struct {
+	// name of the list kind, used to key the list in the frontier
+	name         string
+	pathFormat   string
+	policy       *ScrapingPolicy
+	collector    *colly.Collector
+	sightingChan chan model.CandidateSighting
+	// nil if the scraping progress is not checkpointed
+	frontier *Frontier
+}
+
+func NewUserListScraper(policy *ScrapingPolicy, name, pathFormat string, sightingChanSize int, frontier *Frontier) *UserListScraper {
+	userListScraper := &UserListScraper{
+		name:         name,
+		pathFormat:   pathFormat,
+		policy:       policy,
+		collector:    policy.newCollector(),
+		sightingChan: make(chan model.CandidateSighting, sightingChanSize),
+		frontier:     frontier,
+	}
+	userListScraper.collector.OnHTML("div.mainWrapper", userListScraper.handleMainWrapper)
+	return userListScraper
+}
+
+// Crawl scrapes the list from where the frontier left it, and returns false if the list was already completed
+func (scraper *UserListScraper) Crawl(listId string) bool {
+	page, completed := 1, false
+	if scraper.frontier != nil {
+		page, completed = scraper.frontier.NextPage(scraper.frontierKey(listId))
+	}
+	if completed {
+		log.Debug().Msgf("%s list %s was completed before. Skipping", scraper.name, listId)
+		return false
+	}
+
+	ctx := colly.NewContext()
+	ctx.Put("listId", listId)
+
+	scraper.collector.Request("GET", scraper.policy.Mirrors.URL(fmt.Sprintf(scraper.pathFormat, listId, page)), nil, ctx, nil)
+	scraper.collector.Wait()
+	return true
+}
+
+func (scraper *UserListScraper) CloseSightingChan() {
+	close(scraper.sightingChan)
+}
+
+// CollectSightings drains the sightings until the channel is closed
+func (scraper *UserListScraper) CollectSightings() []model.CandidateSighting {
+	return collectSightings(scraper.sightingChan)
+}
+
+func (scraper *UserListScraper) handleMainWrapper(page *colly.HTMLElement) {
+	log.Debug().Msgf("UserListScraper processing page %s", page.Request.URL.String())
+	listId := page.Request.Ctx.Get("listId")
+	if listId == "" {
+		log.Error().Msgf("List id not found in context. Skipping %s", page.Request.URL.String())
+		return
+	}
+
+	page.ForEach(userListAvatarSelector, func(_ int, avatar *colly.HTMLElement) {
+		// avatars link to the user home page, e.g. /user/sai
+		href := strings.TrimSuffix(avatar.Attr("href"), "/")
+		if !strings.Contains(href, "/user/") {
+			log.Debug().Msgf("Unexpected user link %s on %s. Skipping...", href, page.Request.URL.String())
+			return
+		}
+		scraper.sightingChan <- model.CandidateSighting{
+			UserID:    path.Base(href),
+			Source:    scraper.frontierKey(listId),
+			SightedAt: time.Now(),
+		}
+	})
+
+	maxIndex, err := getMaxIndex(page.DOM)
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to get max index. Skipping %s", page.Request.URL.String())
+		return
+	}
+	curIndex, err := getCurIndex(page.Request.URL)
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to get current index. Skipping %s", page.Request.URL.String())
+		return
+	}
+
+	if curIndex < maxIndex {
+		nextPageAddr := scraper.policy.Mirrors.URL(fmt.Sprintf(scraper.pathFormat, listId, curIndex+1))
+		log.Debug().Msgf("Visiting next page %s", nextPageAddr)
+		if scraper.frontier != nil {
+			scraper.frontier.SetPendingPage(scraper.frontierKey(listId), curIndex+1)
+		}
+		page.Request.Visit(nextPageAddr)
+	} else if scraper.frontier != nil {
+		scraper.frontier.Complete(scraper.frontierKey(listId))
+	}
+}
+
+func (scraper *UserListScraper) frontierKey(listId string) string {
+	return scraper.name + "/" + listId
+}
+
+// collectSightings keeps one sighting per user and source
+func collectSightings(sightingChan chan model.CandidateSighting) []model.CandidateSighting {
+	sightingSet := make(map[[2]string]model.CandidateSighting)
+	for sighting := range sightingChan {
+		sightingSet[[2]string{sighting.UserID, sighting.Source}] = sighting
+	}
+
+	sightings := make([]model.CandidateSighting, 0, len(sightingSet))
+	for _, sighting := range sightingSet {
+		sightings = append(sightings, sighting)
+	}
+	return sightings
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"archive/zip"
+	"bufio"
+	"fmt"
+	"time"
+
+	dao "github.com/AlcEccentric/beck-mizuki/dao"
+	model "github.com/AlcEccentric/beck-mizuki/model"
+	"github.com/rs/zerolog/log"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	archiveSubjectFile           = "subject.jsonlines"
+	archivePersonFile            = "person.jsonlines"
+	archiveCharacterFile         = "character.jsonlines"
+	archiveSubjectRelationFile   = "subject-relations.jsonlines"
+	archiveSubjectPersonFile     = "subject-persons.jsonlines"
+	archiveSubjectCharacterFile  = "subject-characters.jsonlines"
+	archiveMaxLineSizeInBytes    = 16 * 1024 * 1024
+	archiveInsertBatchSize       = 500
+	archiveProgressLogLineNumber = 100000
+)
+
+// ArchiveImportService imports the Bangumi Archive dump (https://github.com/bangumi/Archive),
+// a zip of json lines files, into the subject, person, character and relation tables
+type ArchiveImportService struct {
+	konomiAccessor dao.KonomiAccessor
+}
+
+func NewArchiveImportService(konomiAccessor dao.KonomiAccessor) *ArchiveImportService {
+	return &ArchiveImportService{
+		konomiAccessor: konomiAccessor,
+	}
+}
+
+func (svc *ArchiveImportService) Import(dumpPath string) error {
+	dump, err := zip.OpenReader(dumpPath)
+	if err != nil {
+		return fmt.Errorf("failed to open archive dump %s (%w)", dumpPath, err)
+	}
+	defer dump.Close()
+
+	archivedAt := getArchivedAt(dump)
+	log.Info().Msgf("Importing archive dump %s made at %s", dumpPath, archivedAt)
+
+	if err := svc.importSubjects(dump, archivedAt); err != nil {
+		return err
+	}
+	if err := svc.importPersons(dump); err != nil {
+		return err
+	}
+	if err := svc.importCharacters(dump); err != nil {
+		return err
+	}
+	if err := svc.importSubjectRelations(dump); err != nil {
+		return err
+	}
+	if err := svc.importSubjectPersons(dump); err != nil {
+		return err
+	}
+	return svc.importSubjectCharacters(dump)
+}
+
+func (svc *ArchiveImportService) importSubjects(dump *zip.ReadCloser, archivedAt time.Time) error {
+	subjects := make([]model.Subject, 0, archiveInsertBatchSize)
+	flush := func() error {
+		err := svc.konomiAccessor.BatchInsertArchivedSubject(subjects, archivedAt, archiveInsertBatchSize)
+		subjects = subjects[:0]
+		return err
+	}
+
+	return forEachArchiveLine(dump, archiveSubjectFile, func(line gjson.Result) error {
+		subjects = append(subjects, model.Subject{
+			Id:              line.Get("id").String(),
+			Type:            model.SubjectType(line.Get("type").Int()),
+			Name:            line.Get("name").String(),
+			NameCn:          line.Get("name_cn").String(),
+			AvgRating:       float32(line.Get("score").Float()),
+			Platform:        line.Get("platform").Int(),
+			AirDate:         line.Get("date").String(),
+			Rank:            line.Get("rank").Int(),
+			CollectionTotal: getArchivedCollectionTotal(line.Get("favorite")),
+		})
+		if len(subjects) == archiveInsertBatchSize {
+			return flush()
+		}
+		return nil
+	}, flush)
+}
+
+func (svc *ArchiveImportService) importPersons(dump *zip.ReadCloser) error {
+	persons := make([]model.Person, 0, archiveInsertBatchSize)
+	flush := func() error {
+		err := svc.konomiAccessor.BatchInsertPerson(persons, archiveInsertBatchSize)
+		persons = persons[:0]
+		return err
+	}
+
+	return forEachArchiveLine(dump, archivePersonFile, func(line gjson.Result) error {
+		career := make([]string, 0)
+		for _, careerResult := range line.Get("career").Array() {
+			career = append(career, careerResult.String())
+		}
+		persons = append(persons, model.Person{
+			Id:     line.Get("id").String(),
+			Name:   line.Get("name").String(),
+			Type:   line.Get("type").Int(),
+			Career: career,
+		})
+		if len(persons) == archiveInsertBatchSize {
+			return flush()
+		}
+		return nil
+	}, flush)
+}
+
+func (svc *ArchiveImportService) importCharacters(dump *zip.ReadCloser) error {
+	characters := make([]model.Character, 0, archiveInsertBatchSize)
+	flush := func() error {
+		err := svc.konomiAccessor.BatchInsertCharacter(characters, archiveInsertBatchSize)
+		characters = characters[:0]
+		return err
+	}
+
+	return forEachArchiveLine(dump, archiveCharacterFile, func(line gjson.Result) error {
+		characters = append(characters, model.Character{
+			Id:   line.Get("id").String(),
+			Name: line.Get("name").String(),
+			Type: line.Get("role").Int(),
+		})
+		if len(characters) == archiveInsertBatchSize {
+			return flush()
+		}
+		return nil
+	}, flush)
+}
+
+func (svc *ArchiveImportService) importSubjectRelations(dump *zip.ReadCloser) error {
+	// a batch must not upsert the same row twice, so duplicated edges are dropped
+	relations := make(map[[2]string]model.SubjectRelation, archiveInsertBatchSize)
+	flush := func() error {
+		batch := make([]model.SubjectRelation, 0, len(relations))
+		for _, relation := range relations {
+			batch = append(batch, relation)
+		}
+		clear(relations)
+		return svc.konomiAccessor.BatchInsertSubjectRelation(batch, archiveInsertBatchSize)
+	}
+
+	return forEachArchiveLine(dump, archiveSubjectRelationFile, func(line gjson.Result) error {
+		relationType := model.SubjectRelationType(line.Get("relation_type").Int())
+		relation := model.SubjectRelation{
+			SubjectID:        line.Get("subject_id").String(),
+			RelatedSubjectID: line.Get("related_subject_id").String(),
+			RelationType:     relationType,
+			Relation:         relationType.Label(),
+		}
+		relations[[2]string{relation.SubjectID, relation.RelatedSubjectID}] = relation
+		if len(relations) == archiveInsertBatchSize {
+			return flush()
+		}
+		return nil
+	}, flush)
+}
+
+func (svc *ArchiveImportService) importSubjectPersons(dump *zip.ReadCloser) error {
+	subjectPersons := make(map[model.SubjectPerson]struct{}, archiveInsertBatchSize)
+	flush := func() error {
+		batch := make([]model.SubjectPerson, 0, len(subjectPersons))
+		for subjectPerson := range subjectPersons {
+			batch = append(batch, subjectPerson)
+		}
+		clear(subjectPersons)
+		return svc.konomiAccessor.BatchInsertSubjectPerson(batch, archiveInsertBatchSize)
+	}
+
+	return forEachArchiveLine(dump, archiveSubjectPersonFile, func(line gjson.Result) error {
+		position := line.Get("position").Int()
+		role := model.StaffPositionLabel(position)
+		if role == "" {
+			// role is part of the key, keep persons with unlisted positions apart
+			// the api labels such positions with a relation unknown here, so their rows may not match the api ones
+			role = fmt.Sprintf("position-%d", position)
+		}
+		subjectPersons[model.SubjectPerson{
+			SubjectID: line.Get("subject_id").String(),
+			PersonID:  line.Get("person_id").String(),
+			Role:      role,
+			Position:  position,
+		}] = struct{}{}
+		if len(subjectPersons) == archiveInsertBatchSize {
+			return flush()
+		}
+		return nil
+	}, flush)
+}
+
+func (svc *ArchiveImportService) importSubjectCharacters(dump *zip.ReadCloser) error {
+	subjectCharacters := make(map[[2]string]model.SubjectCharacter, archiveInsertBatchSize)
+	flush := func() error {
+		batch := make([]model.SubjectCharacter, 0, len(subjectCharacters))
+		for _, subjectCharacter := range subjectCharacters {
+			batch = append(batch, subjectCharacter)
+		}
+		clear(subjectCharacters)
+		return svc.konomiAccessor.BatchInsertSubjectCharacter(batch, archiveInsertBatchSize)
+	}
+
+	return forEachArchiveLine(dump, archiveSubjectCharacterFile, func(line gjson.Result) error {
+		subjectCharacter := model.SubjectCharacter{
+			SubjectID:   line.Get("subject_id").String(),
+			CharacterID: line.Get("character_id").String(),
+			Role:        model.CharacterRoleLabel(line.Get("type").Int()),
+		}
+		subjectCharacters[[2]string{subjectCharacter.SubjectID, subjectCharacter.CharacterID}] = subjectCharacter
+		if len(subjectCharacters) == archiveInsertBatchSize {
+			return flush()
+		}
+		return nil
+	}, flush)
+}
+
+// forEachArchiveLine calls handleLine on every line of the given file in the dump and flush once all lines are handled
+// Lines that fail to be handled are logged and skipped so that a bad batch does not abort the whole import
+func forEachArchiveLine(dump *zip.ReadCloser, fileName string, handleLine func(gjson.Result) error, flush func() error) error {
+	file, err := dump.Open(fileName)
+	if err != nil {
+		return fmt.Errorf("failed to open %s in archive dump (%w)", fileName, err)
+	}
+	defer file.Close()
+
+	log.Info().Msgf("Importing %s from archive dump", fileName)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), archiveMaxLineSizeInBytes)
+	lineCnt, failedCnt := 0, 0
+	for scanner.Scan() {
+		lineCnt++
+		if err := handleLine(gjson.ParseBytes(scanner.Bytes())); err != nil {
+			failedCnt++
+			log.Error().Err(err).Msgf("Failed to import batch ending at line %d of %s. Skipping...", lineCnt, fileName)
+		}
+		if lineCnt%archiveProgressLogLineNumber == 0 {
+			log.Info().Msgf("Imported %d lines of %s", lineCnt, fileName)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s in archive dump at line %d (%w)", fileName, lineCnt, err)
+	}
+	if err := flush(); err != nil {
+		failedCnt++
+		log.Error().Err(err).Msgf("Failed to import last batch of %s", fileName)
+	}
+
+	log.Info().Msgf("Imported %d lines of %s with %d failed batches", lineCnt, fileName, failedCnt)
+	return nil
+}
+
+// getArchivedAt approximates the time the dump was made with the latest modified time of its files
+func getArchivedAt(dump *zip.ReadCloser) time.Time {
+	archivedAt := time.Time{}
+	for _, file := range dump.File {
+		if file.Modified.After(archivedAt) {
+			archivedAt = file.Modified
+		}
+	}
+	if archivedAt.IsZero() {
+		log.Warn().Msg("Archive dump has no modified time. Falling back to now")
+		return time.Now()
+	}
+	return archivedAt
+}
+
+func getArchivedCollectionTotal(favorite gjson.Result) int64 {
+	return favorite.Get("wish").Int() + favorite.Get("done").Int() + favorite.Get("doing").Int() +
+		favorite.Get("on_hold").Int() + favorite.Get("dropped").Int()
+}
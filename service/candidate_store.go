@@ -0,0 +1,55 @@
+package service
+
+import (
+	dao "github.com/AlcEccentric/beck-mizuki/dao"
+	model "github.com/AlcEccentric/beck-mizuki/model"
+)
+
+const candidateSightingInsertBatchSize = 500
+
+// CandidateStore keeps the candidates of a cold start in the database instead of memory
+// It is safe for concurrent use, and survives a crash so that a resumed run keeps the candidates found before
+type CandidateStore struct {
+	konomiAccessor dao.KonomiAccessor
+}
+
+func NewCandidateStore(konomiAccessor dao.KonomiAccessor) *CandidateStore {
+	return &CandidateStore{
+		konomiAccessor: konomiAccessor,
+	}
+}
+
+// Add stores the sightings, keeping the latest one of a user on a source
+// as an upsert cannot touch the same sighting twice in one statement
+func (store *CandidateStore) Add(sightings []model.CandidateSighting) error {
+	type sightingKey struct {
+		userID string
+		source string
+	}
+	latestIdx := make(map[sightingKey]int, len(sightings))
+	uniqueSightings := make([]model.CandidateSighting, 0, len(sightings))
+	for _, sighting := range sightings {
+		key := sightingKey{userID: sighting.UserID, source: sighting.Source}
+		if idx, ok := latestIdx[key]; ok {
+			if sighting.SightedAt.After(uniqueSightings[idx].SightedAt) {
+				uniqueSightings[idx] = sighting
+			}
+			continue
+		}
+		latestIdx[key] = len(uniqueSightings)
+		uniqueSightings = append(uniqueSightings, sighting)
+	}
+	return store.konomiAccessor.BatchInsertCandidateSighting(uniqueSightings, candidateSightingInsertBatchSize)
+}
+
+func (store *CandidateStore) Count() (int, error) {
+	return store.konomiAccessor.GetCandidateCount()
+}
+
+func (store *CandidateStore) Candidates(offset, limit int) ([]model.Candidate, error) {
+	return store.konomiAccessor.GetCandidatesPaginated(offset, limit)
+}
+
+func (store *CandidateStore) Clear() error {
+	return store.konomiAccessor.DeleteCandidateSightings()
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"time"
+
+	dao "github.com/AlcEccentric/beck-mizuki/dao"
+	model "github.com/AlcEccentric/beck-mizuki/model"
+	"github.com/AlcEccentric/beck-mizuki/scraper"
+	util "github.com/AlcEccentric/beck-mizuki/util"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	userFriendInsertBatchSize  = 100
+	friendListSightingChanSize = 100
+)
+
+// FriendGraphService walks the friend graph on the website and keeps its edges
+type FriendGraphService struct {
+	konomiAccessor dao.KonomiAccessor
+	policy         *scraper.ScrapingPolicy
+}
+
+func NewFriendGraphService(konomiAccessor dao.KonomiAccessor, policy *scraper.ScrapingPolicy) *FriendGraphService {
+	return &FriendGraphService{
+		konomiAccessor: konomiAccessor,
+		policy:         policy,
+	}
+}
+
+// Snowball scrapes friend lists breadth-first from the seed users up to maxDepth hops,
+// and returns the uids found that are not seeds, stopping once maxUids such uids are found
+// The friend edges of every scraped list are persisted along the way
+func (svc *FriendGraphService) Snowball(seedUids []string, maxDepth, maxUids int) []string {
+	seen := make(map[string]struct{}, len(seedUids))
+	for _, uid := range seedUids {
+		seen[uid] = struct{}{}
+	}
+
+	discoveredUids := make([]string, 0)
+	level := seedUids
+	for depth := 1; depth <= maxDepth && len(level) > 0; depth++ {
+		log.Info().Msgf("Scraping friend lists of %d users at depth %d", len(level), depth)
+		nextLevel := make([]string, 0)
+		for _, uid := range level {
+			if len(discoveredUids) >= maxUids {
+				log.Info().Msgf("Reached the budget of %d discovered users. Stop scraping friend lists", maxUids)
+				return discoveredUids
+			}
+
+			for _, friendId := range svc.scrapeFriends(uid) {
+				if _, ok := seen[friendId]; ok {
+					continue
+				}
+				seen[friendId] = struct{}{}
+				if len(discoveredUids) < maxUids {
+					discoveredUids = append(discoveredUids, friendId)
+					nextLevel = append(nextLevel, friendId)
+				}
+			}
+		}
+		log.Info().Msgf("Discovered %d new users at depth %d", len(nextLevel), depth)
+		level = nextLevel
+	}
+	return discoveredUids
+}
+
+// scrapeFriends returns the friends of the user and persists the edges to them
+func (svc *FriendGraphService) scrapeFriends(uid string) []string {
+	friendListScraper := scraper.NewUserListScraper(svc.policy, "friends", util.UserFriendsPathFormat, friendListSightingChanSize, nil)
+	go func() {
+		friendListScraper.Crawl(uid)
+		friendListScraper.CloseSightingChan()
+	}()
+	friendIds := make([]string, 0)
+	for _, sighting := range friendListScraper.CollectSightings() {
+		friendIds = append(friendIds, sighting.UserID)
+	}
+	log.Debug().Msgf("Found %d friends of user %s", len(friendIds), uid)
+
+	discoveredAt := time.Now()
+	friends := make([]model.UserFriend, 0, len(friendIds))
+	for _, friendId := range friendIds {
+		friends = append(friends, model.UserFriend{
+			UserID:       uid,
+			FriendID:     friendId,
+			DiscoveredAt: discoveredAt,
+		})
+	}
+	if err := svc.konomiAccessor.BatchInsertUserFriend(friends, userFriendInsertBatchSize); err != nil {
+		log.Error().Err(err).Msgf("Failed to persist %d friend edges of user %s", len(friends), uid)
+	}
+	return friendIds
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	job "github.com/AlcEccentric/beck-mizuki/model/job"
+	"github.com/rs/zerolog/log"
+)
+
+type SubjectIdReadingService struct {
+}
+
+func NewSubjectIdReadingService() *SubjectIdReadingService {
+	return &SubjectIdReadingService{}
+}
+
+// GetSubjectIdReader reads the subject ids to sync with readSubjectIds and splits them into jobs of batchSize subjects
+func (svc *SubjectIdReadingService) GetSubjectIdReader(readSubjectIds func() ([]string, error), batchSize int) func(put func(*job.SubjectSyncOrchJob)) error {
+	return func(put func(*job.SubjectSyncOrchJob)) error {
+		log.Info().Msg("Reading subject ids to sync from database")
+
+		sids, err := readSubjectIds()
+		if err != nil {
+			return err
+		}
+		log.Info().Msgf("Found %d subjects to sync", len(sids))
+
+		for startIdx := 0; startIdx < len(sids); startIdx += batchSize {
+			endIdx := startIdx + batchSize
+			if endIdx > len(sids) {
+				endIdx = len(sids)
+			}
+			put(&job.SubjectSyncOrchJob{
+				SubjectIds: sids[startIdx:endIdx],
+			})
+		}
+		return nil
+	}
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"strconv"
+	"time"
+
+	dao "github.com/AlcEccentric/beck-mizuki/dao"
+	job "github.com/AlcEccentric/beck-mizuki/model/job"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	relationInsertBatchSize = 100
+)
+
+type SubjectRelationService struct {
+	bgmClient      *dao.BgmApiAccessor
+	konomiAccessor dao.KonomiAccessor
+}
+
+func NewSubjectRelationService(bgmClient *dao.BgmApiAccessor, konomiAccessor dao.KonomiAccessor) *SubjectRelationService {
+	return &SubjectRelationService{
+		bgmClient:      bgmClient,
+		konomiAccessor: konomiAccessor,
+	}
+}
+
+func (svc *SubjectRelationService) GetRelationSyncer() func(in *job.SubjectSyncOrchJob) (*job.SubjectSyncOrchJob, error) {
+	return func(in *job.SubjectSyncOrchJob) (*job.SubjectSyncOrchJob, error) {
+		log.Info().Msgf("Syncing relations for %d subjects", len(in.SubjectIds))
+		for _, sid := range in.SubjectIds {
+			relations, relatedSubjects, err := svc.bgmClient.GetSubjectRelations(sid)
+			if err != nil {
+				log.Error().Err(err).Msgf("Failed to get relations for subject: %s. Skipping...", sid)
+				continue
+			}
+
+			// related subjects are stored as well so that every relation edge ends at a known subject
+			if err := svc.konomiAccessor.BatchInsertSubject(relatedSubjects, relationInsertBatchSize); err != nil {
+				log.Error().Err(err).Msgf("Failed to persist related subjects of subject: %s. Skipping...", sid)
+				continue
+			}
+			if err := svc.konomiAccessor.BatchInsertSubjectRelation(relations, relationInsertBatchSize); err != nil {
+				log.Error().Err(err).Msgf("Failed to persist relations of subject: %s. Skipping...", sid)
+				continue
+			}
+			if err := svc.konomiAccessor.MarkSubjectRelationSynced(sid, time.Now()); err != nil {
+				log.Error().Err(err).Msgf("Failed to mark relations of subject: %s as synced", sid)
+			}
+			log.Debug().Msgf("Synced %d relations for subject: %s", len(relations), sid)
+		}
+		return in, nil
+	}
+}
+
+// UpdateFranchises groups subjects connected by franchise relations (see model.SubjectRelationType.IsFranchise)
+// and stores the smallest subject id of each group as the franchise id of its subjects
+func (svc *SubjectRelationService) UpdateFranchises() error {
+	sids, err := svc.konomiAccessor.GetBgmSubjectIds()
+	if err != nil {
+		return err
+	}
+	relations, err := svc.konomiAccessor.GetSubjectRelations()
+	if err != nil {
+		return err
+	}
+
+	franchises := newFranchiseSet(sids)
+	for _, relation := range relations {
+		if relation.RelationType.IsFranchise() {
+			franchises.union(relation.SubjectID, relation.RelatedSubjectID)
+		}
+	}
+
+	franchiseIds := make(map[string]string, len(sids))
+	for _, sid := range sids {
+		franchiseIds[sid] = franchises.find(sid)
+	}
+	log.Info().Msgf("Grouped %d subjects into %d franchises", len(sids), franchises.size())
+	return svc.konomiAccessor.BatchUpdateFranchiseId(franchiseIds, relationInsertBatchSize)
+}
+
+// franchiseSet is a union find over subject ids whose representative is the smallest subject id
+type franchiseSet struct {
+	parents map[string]string
+}
+
+func newFranchiseSet(sids []string) *franchiseSet {
+	parents := make(map[string]string, len(sids))
+	for _, sid := range sids {
+		parents[sid] = sid
+	}
+	return &franchiseSet{
+		parents: parents,
+	}
+}
+
+func (set *franchiseSet) find(sid string) string {
+	parent, ok := set.parents[sid]
+	if !ok {
+		set.parents[sid] = sid
+		return sid
+	}
+	if parent == sid {
+		return sid
+	}
+	root := set.find(parent)
+	set.parents[sid] = root
+	return root
+}
+
+func (set *franchiseSet) union(sid, otherSid string) {
+	root, otherRoot := set.find(sid), set.find(otherSid)
+	if root == otherRoot {
+		return
+	}
+	if isSmallerSubjectId(root, otherRoot) {
+		set.parents[otherRoot] = root
+	} else {
+		set.parents[root] = otherRoot
+	}
+}
+
+func (set *franchiseSet) size() int {
+	cnt := 0
+	for sid, parent := range set.parents {
+		if sid == parent {
+			cnt++
+		}
+	}
+	return cnt
+}
+
+func isSmallerSubjectId(sid, otherSid string) bool {
+	id, err := strconv.Atoi(sid)
+	otherId, otherErr := strconv.Atoi(otherSid)
+	if err != nil || otherErr != nil {
+		return sid < otherSid
+	}
+	return id < otherId
+}
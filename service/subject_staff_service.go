@@ -0,0 +1,139 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	dao "github.com/AlcEccentric/beck-mizuki/dao"
+	model "github.com/AlcEccentric/beck-mizuki/model"
+	job "github.com/AlcEccentric/beck-mizuki/model/job"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	staffInsertBatchSize = 100
+)
+
+type SubjectStaffService struct {
+	bgmClient      *dao.BgmApiAccessor
+	konomiAccessor dao.KonomiAccessor
+	// persons and characters already persisted in this run, as most of them appear in many subjects
+	persistedLock       sync.Mutex
+	persistedPersons    map[string]struct{}
+	persistedCharacters map[string]struct{}
+}
+
+func NewSubjectStaffService(bgmClient *dao.BgmApiAccessor, konomiAccessor dao.KonomiAccessor) *SubjectStaffService {
+	return &SubjectStaffService{
+		bgmClient:           bgmClient,
+		konomiAccessor:      konomiAccessor,
+		persistedPersons:    make(map[string]struct{}),
+		persistedCharacters: make(map[string]struct{}),
+	}
+}
+
+func (svc *SubjectStaffService) GetStaffSyncer() func(in *job.SubjectSyncOrchJob) (*job.SubjectSyncOrchJob, error) {
+	return func(in *job.SubjectSyncOrchJob) (*job.SubjectSyncOrchJob, error) {
+		log.Info().Msgf("Syncing staff and characters for %d subjects", len(in.SubjectIds))
+		for _, sid := range in.SubjectIds {
+			if err := svc.syncPersons(sid); err != nil {
+				log.Error().Err(err).Msgf("Failed to sync persons for subject: %s. Skipping...", sid)
+				continue
+			}
+			if err := svc.syncCharacters(sid); err != nil {
+				log.Error().Err(err).Msgf("Failed to sync characters for subject: %s. Skipping...", sid)
+				continue
+			}
+			if err := svc.konomiAccessor.MarkSubjectStaffSynced(sid, time.Now()); err != nil {
+				log.Error().Err(err).Msgf("Failed to mark staff of subject: %s as synced", sid)
+			}
+		}
+		return in, nil
+	}
+}
+
+func (svc *SubjectStaffService) syncPersons(sid string) error {
+	persons, subjectPersons, err := svc.bgmClient.GetSubjectPersons(sid)
+	if err != nil {
+		return err
+	}
+
+	newPersons := make([]model.Person, 0, len(persons))
+	svc.persistedLock.Lock()
+	for _, person := range persons {
+		// the same person shows up once per role in a subject
+		if _, ok := svc.persistedPersons[person.Id]; !ok {
+			svc.persistedPersons[person.Id] = struct{}{}
+			newPersons = append(newPersons, person)
+		}
+	}
+	svc.persistedLock.Unlock()
+
+	if err := svc.konomiAccessor.BatchInsertPerson(newPersons, staffInsertBatchSize); err != nil {
+		svc.forget(svc.persistedPersons, personIds(newPersons))
+		return err
+	}
+	log.Debug().Msgf("Synced %d persons (%d new in this run) for subject: %s", len(subjectPersons), len(newPersons), sid)
+	return svc.konomiAccessor.BatchInsertSubjectPerson(uniqueSubjectPersons(subjectPersons), staffInsertBatchSize)
+}
+
+func (svc *SubjectStaffService) syncCharacters(sid string) error {
+	characters, subjectCharacters, err := svc.bgmClient.GetSubjectCharacters(sid)
+	if err != nil {
+		return err
+	}
+
+	newCharacters := make([]model.Character, 0, len(characters))
+	svc.persistedLock.Lock()
+	for _, character := range characters {
+		if _, ok := svc.persistedCharacters[character.Id]; !ok {
+			svc.persistedCharacters[character.Id] = struct{}{}
+			newCharacters = append(newCharacters, character)
+		}
+	}
+	svc.persistedLock.Unlock()
+
+	if err := svc.konomiAccessor.BatchInsertCharacter(newCharacters, staffInsertBatchSize); err != nil {
+		svc.forget(svc.persistedCharacters, characterIds(newCharacters))
+		return err
+	}
+	log.Debug().Msgf("Synced %d characters (%d new in this run) for subject: %s", len(subjectCharacters), len(newCharacters), sid)
+	return svc.konomiAccessor.BatchInsertSubjectCharacter(subjectCharacters, staffInsertBatchSize)
+}
+
+// forget removes ids that failed to persist so that a later subject can retry them
+func (svc *SubjectStaffService) forget(persisted map[string]struct{}, ids []string) {
+	svc.persistedLock.Lock()
+	defer svc.persistedLock.Unlock()
+	for _, id := range ids {
+		delete(persisted, id)
+	}
+}
+
+func personIds(persons []model.Person) []string {
+	ids := make([]string, 0, len(persons))
+	for _, person := range persons {
+		ids = append(ids, person.Id)
+	}
+	return ids
+}
+
+func characterIds(characters []model.Character) []string {
+	ids := make([]string, 0, len(characters))
+	for _, character := range characters {
+		ids = append(ids, character.Id)
+	}
+	return ids
+}
+
+func uniqueSubjectPersons(subjectPersons []model.SubjectPerson) []model.SubjectPerson {
+	seen := make(map[model.SubjectPerson]struct{}, len(subjectPersons))
+	unique := make([]model.SubjectPerson, 0, len(subjectPersons))
+	for _, subjectPerson := range subjectPersons {
+		if _, ok := seen[subjectPerson]; !ok {
+			seen[subjectPerson] = struct{}{}
+			unique = append(unique, subjectPerson)
+		}
+	}
+	return unique
+}
@@ -3,22 +3,39 @@ package service
 import (
 	dao "github.com/AlcEccentric/beck-mizuki/dao"
 	"github.com/AlcEccentric/beck-mizuki/model/job"
+	"github.com/rs/zerolog/log"
 )
 
 type UserCleaningService struct {
 	konomiAccessor dao.KonomiAccessor
+	// number of consecutive failed activity checks before a user is removed
+	strikesBeforeRemoval int
 }
 
-func NewUserCleaningService(konomiAccessor dao.KonomiAccessor) *UserCleaningService {
+func NewUserCleaningService(konomiAccessor dao.KonomiAccessor, strikesBeforeRemoval int) *UserCleaningService {
 	return &UserCleaningService{
-		konomiAccessor: konomiAccessor,
+		konomiAccessor:       konomiAccessor,
+		strikesBeforeRemoval: strikesBeforeRemoval,
 	}
 }
 
+// GetUserCleaner marks users failing the activity check inactive, and only removes them once they failed strikesBeforeRemoval checks in a row
 func (svc *UserCleaningService) GetUserCleaner() func(in *job.RegularUpdateOrchJob) (*job.RegularUpdateOrchJob, error) {
 	return func(in *job.RegularUpdateOrchJob) (*job.RegularUpdateOrchJob, error) {
 		for _, uid := range in.InactiveUserIds {
+			consecutiveFailures, err := svc.konomiAccessor.MarkUserInactive(uid)
+			if err != nil {
+				log.Error().Err(err).Msgf("Failed to mark user: %s inactive. Skipping...", uid)
+				continue
+			}
+
+			if consecutiveFailures < svc.strikesBeforeRemoval {
+				log.Info().Msgf("User %s failed %d/%d activity checks in a row and is inactive", uid, consecutiveFailures, svc.strikesBeforeRemoval)
+				continue
+			}
+			log.Info().Msgf("User %s failed %d activity checks in a row and will be removed", uid, consecutiveFailures)
 			svc.konomiAccessor.DeleteCollectionByUid(uid)
+			svc.konomiAccessor.DeleteCollectionDetailByUid(uid)
 			svc.konomiAccessor.DeleteUser(uid)
 		}
 		return in, nil
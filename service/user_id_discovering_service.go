@@ -0,0 +1,170 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	model "github.com/AlcEccentric/beck-mizuki/model"
+	job "github.com/AlcEccentric/beck-mizuki/model/job"
+	"github.com/AlcEccentric/beck-mizuki/scraper"
+	util "github.com/AlcEccentric/beck-mizuki/util"
+	"github.com/rs/zerolog/log"
+)
+
+// UserIdDiscoverer is a source of cold start candidates
+// Discover puts jobs carrying either subjects, whose pages are scraped by the user id retriever, or users sighted directly
+type UserIdDiscoverer interface {
+	Name() string
+	Discover(put func(*job.ColdStartOrchJob)) error
+}
+
+// GetUserIdDiscoverer runs all discoverers concurrently as the producer of the cold start pipeline
+// A failing discoverer is logged and does not stop the others
+func GetUserIdDiscoverer(discoverers []UserIdDiscoverer) func(put func(*job.ColdStartOrchJob)) error {
+	return func(put func(*job.ColdStartOrchJob)) error {
+		var wg sync.WaitGroup
+		for _, discoverer := range discoverers {
+			wg.Add(1)
+			go func(discoverer UserIdDiscoverer) {
+				defer wg.Done()
+				log.Info().Msgf("Discovering user ids from %s", discoverer.Name())
+				if err := discoverer.Discover(put); err != nil {
+					log.Error().Err(err).Msgf("Failed to discover user ids from %s. Skipping...", discoverer.Name())
+				}
+			}(discoverer)
+		}
+		wg.Wait()
+		return nil
+	}
+}
+
+// SubjectDiscoverer puts the anime subjects whose pages are scraped for users
+type SubjectDiscoverer struct {
+	subjectSvc             *SubjectService
+	numOfSubjectRetrievers int
+}
+
+func NewSubjectDiscoverer(subjectSvc *SubjectService, numOfSubjectRetrievers int) *SubjectDiscoverer {
+	return &SubjectDiscoverer{
+		subjectSvc:             subjectSvc,
+		numOfSubjectRetrievers: numOfSubjectRetrievers,
+	}
+}
+
+func (discoverer *SubjectDiscoverer) Name() string {
+	return "subjects"
+}
+
+func (discoverer *SubjectDiscoverer) Discover(put func(*job.ColdStartOrchJob)) error {
+	return discoverer.subjectSvc.GetSubjectRetriever(discoverer.numOfSubjectRetrievers)(put)
+}
+
+// UserListDiscoverer puts the users listed on paginated user lists, e.g. members of groups or friends of users
+type UserListDiscoverer struct {
+	name       string
+	pathFormat string
+	listIds    []string
+	policy     *scraper.ScrapingPolicy
+	frontier   *scraper.Frontier
+}
+
+func NewGroupMemberDiscoverer(groupIds []string, policy *scraper.ScrapingPolicy, frontier *scraper.Frontier) *UserListDiscoverer {
+	return &UserListDiscoverer{
+		name:       "group",
+		pathFormat: util.GroupMembersPathFormat,
+		listIds:    groupIds,
+		policy:     policy,
+		frontier:   frontier,
+	}
+}
+
+func NewFriendListDiscoverer(rootUids []string, policy *scraper.ScrapingPolicy, frontier *scraper.Frontier) *UserListDiscoverer {
+	return &UserListDiscoverer{
+		name:       "friends",
+		pathFormat: util.UserFriendsPathFormat,
+		listIds:    rootUids,
+		policy:     policy,
+		frontier:   frontier,
+	}
+}
+
+func (discoverer *UserListDiscoverer) Name() string {
+	return discoverer.name
+}
+
+func (discoverer *UserListDiscoverer) Discover(put func(*job.ColdStartOrchJob)) error {
+	for _, listId := range discoverer.listIds {
+		userListScraper := scraper.NewUserListScraper(discoverer.policy, discoverer.name, discoverer.pathFormat, util.SeedUidBatchSize, discoverer.frontier)
+		go func() {
+			userListScraper.Crawl(listId)
+			userListScraper.CloseSightingChan()
+		}()
+
+		sightings := userListScraper.CollectSightings()
+		log.Info().Msgf("Discovered %d user ids from %s list %s", len(sightings), discoverer.name, listId)
+		if len(sightings) > 0 {
+			put(&job.ColdStartOrchJob{Sightings: sightings})
+		}
+	}
+	return nil
+}
+
+const seedFileSource = "seed"
+
+// SeedFileDiscoverer puts the user ids listed in a file, one uid per line
+// Empty lines and lines starting with # are ignored
+type SeedFileDiscoverer struct {
+	path string
+}
+
+func NewSeedFileDiscoverer(path string) *SeedFileDiscoverer {
+	return &SeedFileDiscoverer{
+		path: path,
+	}
+}
+
+func (discoverer *SeedFileDiscoverer) Name() string {
+	return "seed file " + discoverer.path
+}
+
+func (discoverer *SeedFileDiscoverer) Discover(put func(*job.ColdStartOrchJob)) error {
+	file, err := os.Open(discoverer.path)
+	if err != nil {
+		return fmt.Errorf("failed to open seed uid file %s (%w)", discoverer.path, err)
+	}
+	defer file.Close()
+
+	sightings := make([]model.CandidateSighting, 0, util.SeedUidBatchSize)
+	uidCnt := 0
+	sightedAt := time.Now()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		uid := strings.TrimSpace(scanner.Text())
+		if uid == "" || strings.HasPrefix(uid, "#") {
+			continue
+		}
+		sightings = append(sightings, model.CandidateSighting{
+			UserID:    uid,
+			Source:    seedFileSource,
+			SightedAt: sightedAt,
+		})
+		uidCnt++
+		if len(sightings) == util.SeedUidBatchSize {
+			put(&job.ColdStartOrchJob{Sightings: sightings})
+			sightings = make([]model.CandidateSighting, 0, util.SeedUidBatchSize)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read seed uid file %s (%w)", discoverer.path, err)
+	}
+	if len(sightings) > 0 {
+		put(&job.ColdStartOrchJob{Sightings: sightings})
+	}
+
+	log.Info().Msgf("Discovered %d user ids from seed uid file %s", uidCnt, discoverer.path)
+	return nil
+}
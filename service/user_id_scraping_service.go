@@ -1,9 +1,12 @@
 package service
 
 import (
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	dao "github.com/AlcEccentric/beck-mizuki/dao"
 	model "github.com/AlcEccentric/beck-mizuki/model"
 	orchJob "github.com/AlcEccentric/beck-mizuki/model/job"
 	"github.com/AlcEccentric/beck-mizuki/scraper"
@@ -11,52 +14,142 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+const (
+	watermarkUpdateBatchSize         = 100
+	scrapedCollectionInsertBatchSize = 100
+)
+
 type UserIdScrapingService struct {
+	konomiAccessor            dao.KonomiAccessor
+	policy                    *scraper.ScrapingPolicy
+	monitor                   *scraper.ParseMonitor
+	frontier                  *scraper.Frontier
+	subjectPageKinds          []scraper.SubjectPageKind
+	harvestScrapedCollections bool
 }
 
-func NewUserIdScrapingService() *UserIdScrapingService {
-	return &UserIdScrapingService{}
+func NewUserIdScrapingService(konomiAccessor dao.KonomiAccessor, policy *scraper.ScrapingPolicy, frontier *scraper.Frontier, subjectPageKinds []scraper.SubjectPageKind,
+	harvestScrapedCollections bool) *UserIdScrapingService {
+	return &UserIdScrapingService{
+		konomiAccessor:            konomiAccessor,
+		policy:                    policy,
+		monitor:                   scraper.NewParseMonitor(util.ParseFailureRateThreshold, util.ParseFailureMinPages),
+		frontier:                  frontier,
+		subjectPageKinds:          subjectPageKinds,
+		harvestScrapedCollections: harvestScrapedCollections,
+	}
 }
 
 func (svc *UserIdScrapingService) GetUserIdRetriever(coldStartIntervalInDays int) func(in *orchJob.ColdStartOrchJob) (*orchJob.ColdStartOrchJob, error) {
+	watermarks, err := svc.konomiAccessor.GetScrapeWatermarks()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get scrape watermarks. Subjects will be scraped up to the cold start interval")
+		watermarks = make(map[string]time.Time)
+	}
+	log.Info().Msgf("Loaded scrape watermarks of %d subject pages", len(watermarks))
+
 	return func(in *orchJob.ColdStartOrchJob) (*orchJob.ColdStartOrchJob, error) {
-		log.Info().Msgf("Retrieving ids for users who completed some works in the last %d days for %d subjects", coldStartIntervalInDays, len(in.Subjects))
-		subjectUserScraper := scraper.NewSubjectUserScraper(coldStartIntervalInDays, len(in.Subjects))
-
-		var wg sync.WaitGroup
-		for _, subject := range in.Subjects {
-			wg.Add(1)
-			go func(subject model.Subject) {
-				defer wg.Done()
-				subjectUserScraper.Crawl(subject.Id)
-			}(subject)
+		// jobs of non subject discoverers come with their sightings already
+		if len(in.Subjects) == 0 {
+			return in, nil
 		}
 
-		go func() {
-			wg.Wait()
-			subjectUserScraper.CloseUidChan()
-		}()
+		log.Info().Msgf("Retrieving ids for users who collected some works in the last %d days for %d subjects", coldStartIntervalInDays, len(in.Subjects))
+		var collectionsHandler func([]model.ScrapedCollection)
+		if svc.harvestScrapedCollections {
+			collectionsHandler = svc.stageScrapedCollections
+		}
 
-		in.UserIds = subjectUserScraper.CollectUids()
+		crawledPageCnt := 0
+		for _, kind := range svc.subjectPageKinds {
+			sightings, crawledCnt := svc.crawlSubjectPages(kind, in.Subjects, coldStartIntervalInDays, watermarks, collectionsHandler)
+			in.Sightings = append(in.Sightings, sightings...)
+			crawledPageCnt += crawledCnt
+		}
 
-		coolDownPeriodInSeconds := len(in.Subjects) * util.UserIdRetrieverCoolDownSecondsPerSubject
-		log.Info().Msgf("Retrieved uids from %d subjects. Will sleep %d seconds.", len(in.Subjects), coolDownPeriodInSeconds)
+		if err := svc.frontier.Save(); err != nil {
+			log.Error().Err(err).Msg("Failed to save scraper checkpoint")
+		}
+		if err := svc.monitor.Err(); err != nil {
+			// pages of the subjects may be partially parsed, so their watermarks are not moved
+			return nil, fmt.Errorf("aborting cold start as the subject page layout likely changed: %w", err)
+		}
+		svc.updateWatermarks(in.Subjects)
+
+		// subjects completed before resuming were not crawled, no need to cool down for them
+		coolDownPeriodInSeconds := crawledPageCnt * util.UserIdRetrieverCoolDownSecondsPerSubject
+		log.Info().Msgf("Retrieved uids from %d subjects (%d subject pages crawled in this run). Will sleep %d seconds.", len(in.Subjects), crawledPageCnt, coolDownPeriodInSeconds)
 		time.Sleep(time.Duration(coolDownPeriodInSeconds) * time.Second)
 
 		return in, nil
 	}
 }
 
-func (svc *UserIdScrapingService) GetUserIdMerger() (func(in *orchJob.ColdStartOrchJob) (*orchJob.ColdStartOrchJob, error), map[string]struct{}) {
-	userIdSet := make(map[string]struct{})
+// LogParseSummary logs how many scraped pages failed to parse over the whole run
+func (svc *UserIdScrapingService) LogParseSummary() {
+	svc.monitor.LogSummary()
+}
+
+// crawlSubjectPages scrapes the given kind of page of all subjects, and returns the sightings found with the number of subjects crawled
+func (svc *UserIdScrapingService) crawlSubjectPages(kind scraper.SubjectPageKind, subjects []model.Subject, coldStartIntervalInDays int,
+	watermarks map[string]time.Time, collectionsHandler func([]model.ScrapedCollection)) ([]model.CandidateSighting, int) {
+	subjectUserScraper := scraper.NewSubjectUserScraper(svc.policy, svc.monitor, kind, coldStartIntervalInDays, len(subjects), svc.frontier, watermarks, collectionsHandler)
+
+	var wg sync.WaitGroup
+	var crawledSubjectCnt atomic.Int32
+	for _, subject := range subjects {
+		wg.Add(1)
+		go func(subject model.Subject) {
+			defer wg.Done()
+			if subjectUserScraper.Crawl(subject.Id) {
+				crawledSubjectCnt.Add(1)
+			}
+		}(subject)
+	}
+
+	go func() {
+		wg.Wait()
+		subjectUserScraper.CloseSightingChan()
+	}()
+
+	sightings := subjectUserScraper.CollectSightings()
+	log.Info().Msgf("Retrieved %d user sightings from %s pages of %d subjects", len(sightings), kind.Name, len(subjects))
+	return sightings, int(crawledSubjectCnt.Load())
+}
 
-	userMergerFn := func(in *orchJob.ColdStartOrchJob) (*orchJob.ColdStartOrchJob, error) {
-		log.Info().Msgf("Merging fetched user ids into one list (only keep unique user ids)")
-		for _, userId := range in.UserIds {
-			userIdSet[userId] = struct{}{}
+// GetUserIdMerger stores the sightings of each job in the candidate store, which deduplicates them
+// It is safe to run with multiple workers
+// A failed batch fails the pipeline, so that the candidate store and the checkpoint are kept for a resumed run
+func (svc *UserIdScrapingService) GetUserIdMerger(store *CandidateStore) func(in *orchJob.ColdStartOrchJob) (*orchJob.ColdStartOrchJob, error) {
+	return func(in *orchJob.ColdStartOrchJob) (*orchJob.ColdStartOrchJob, error) {
+		log.Info().Msgf("Merging %d user sightings into the candidate store", len(in.Sightings))
+		if err := store.Add(in.Sightings); err != nil {
+			return nil, fmt.Errorf("failed to store %d user sightings (%w)", len(in.Sightings), err)
 		}
 		return in, nil
 	}
+}
 
-	return userMergerFn, userIdSet
+// updateWatermarks stores the newest collection time seen on each completed subject page under its frontier key
+// so that the next cold start stops scraping the page there
+func (svc *UserIdScrapingService) updateWatermarks(subjects []model.Subject) {
+	watermarks := make(map[string]time.Time)
+	for _, kind := range svc.subjectPageKinds {
+		for _, subject := range subjects {
+			if watermark, ok := svc.frontier.NewWatermark(kind.FrontierKey(subject.Id)); ok {
+				watermarks[kind.FrontierKey(subject.Id)] = watermark
+			}
+		}
+	}
+
+	if err := svc.konomiAccessor.BatchUpdateScrapeWatermark(watermarks, watermarkUpdateBatchSize); err != nil {
+		log.Error().Err(err).Msgf("Failed to update scrape watermarks of %d subject pages", len(watermarks))
+	}
+}
+
+// stageScrapedCollections persists the collections of a scraped page right away so that they survive a resumed run
+func (svc *UserIdScrapingService) stageScrapedCollections(collections []model.ScrapedCollection) {
+	if err := svc.konomiAccessor.BatchInsertScrapedCollection(collections, scrapedCollectionInsertBatchSize); err != nil {
+		log.Error().Err(err).Msgf("Failed to stage %d scraped collections", len(collections))
+	}
 }
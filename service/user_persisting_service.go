@@ -2,12 +2,13 @@ package service
 
 import (
 	"fmt"
-	"math"
+	"strings"
 	"time"
 
 	dao "github.com/AlcEccentric/beck-mizuki/dao"
 	"github.com/AlcEccentric/beck-mizuki/helper"
 	model "github.com/AlcEccentric/beck-mizuki/model"
+	"github.com/AlcEccentric/beck-mizuki/util"
 	"github.com/rs/zerolog/log"
 )
 
@@ -16,46 +17,90 @@ const (
 )
 
 type UserPersistingService struct {
-	bgmClient      *dao.BgmApiAccessor
-	konomiAccessor dao.KonomiAccessor
+	bgmClient             *dao.BgmApiAccessor
+	konomiAccessor        dao.KonomiAccessor
+	syncedCollectionTypes []model.CollectionType
+	vipEvaluator          *helper.VipEvaluator
 }
 
-func NewUserPersistenceService(bgmClinet *dao.BgmApiAccessor, konomiAccessor dao.KonomiAccessor) *UserPersistingService {
+func NewUserPersistenceService(bgmClinet *dao.BgmApiAccessor, konomiAccessor dao.KonomiAccessor, syncedCollectionTypes []model.CollectionType,
+	vipEvaluator *helper.VipEvaluator) *UserPersistingService {
 	return &UserPersistingService{
-		bgmClient:      bgmClinet,
-		konomiAccessor: konomiAccessor,
+		bgmClient:             bgmClinet,
+		konomiAccessor:        konomiAccessor,
+		syncedCollectionTypes: syncedCollectionTypes,
+		vipEvaluator:          vipEvaluator,
 	}
 }
 
 func (svc *UserPersistingService) Persist(uids []string) {
 	log.Info().Msgf("Trying to persist %d users", len(uids))
 	persistedUserCnt := 0
+	evaluations, err := svc.konomiAccessor.GetCandidateEvaluations(uids)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to get past candidate evaluations. Evaluating all candidates")
+		evaluations = make(map[string]model.CandidateEvaluation)
+	}
 	for _, uid := range uids {
 		// check if user meets criteria:
 		user, getUserErr := svc.konomiAccessor.GetUser(uid)
 		if getUserErr != nil {
 			// user not found in db, meaning it's a new user
-			isVIP, watchedCollections := helper.IsVip(uid, svc.bgmClient, svc.konomiAccessor)
-			if isVIP {
+			if evaluation, ok := evaluations[uid]; ok && !svc.vipEvaluator.ShouldReevaluate(evaluation, time.Now()) {
+				log.Debug().Msgf("User %s failed rule %s at %s and is unlikely to pass it now. Skipping.", uid, evaluation.FailedRule, evaluation.EvaluatedAt)
+				continue
+			}
+			result := svc.vipEvaluator.IsVip(uid)
+			svc.recordEvaluation(result)
+			if result.Err != nil {
+				log.Error().Err(result.Err).Msgf("Failed to evaluate user: %s. Skipping.", uid)
+			} else if result.Passed {
 				log.Info().Msgf("User %s is new and is a VIP, and will be persisted", uid)
-				svc.insertUserWithQueriedCollections(uid, watchedCollections)
+				log.Debug().Msg(result.String())
+				collections, err := svc.getSyncedCollections(uid, result.Source)
+				if err != nil {
+					log.Error().Err(err).Msgf("Failed to get synced collections for user: %s. Skipping.", uid)
+					continue
+				}
+				if err := svc.insertUserWithQueriedCollections(uid, collections); err != nil {
+					log.Error().Err(err).Msgf("Failed to persist user: %s. Skipping.", uid)
+					continue
+				}
+				svc.updateQualityScore(result)
+				svc.updateUserEvaluation(result)
+				svc.updateBotFlag(uid, result.Flags())
 				persistedUserCnt++
 			} else {
-				log.Info().Msgf("User %s is new but is not a VIP", uid)
+				log.Info().Msgf("User %s is new but is not a VIP as rule %s failed", uid, result.FailedRule)
+				log.Debug().Msg(result.String())
 			}
 		} else {
 			// user already exists in db
 			log.Info().Msgf("User %s already exists in db", uid)
-			daysSinceLastActive := int(math.Ceil(time.Since(user.LastActiveTime).Abs().Hours() / 24.0))
-			filteredWatched, err := svc.bgmClient.GetRecentCollections(uid, model.Watched, model.Anime, helper.AnimeFilter, daysSinceLastActive)
+			// collections are fetched from the start of the day the user was last active on
+			lastActiveDay := util.StartOfDay(user.LastActiveTime, svc.vipEvaluator.CalendarLocation())
+			recentCollections, err := helper.GetAnimeCollections(svc.bgmClient, svc.vipEvaluator.SubjectFilter(), uid, svc.syncedCollectionTypes, lastActiveDay)
 			if err != nil {
-				log.Error().Err(err).Msgf("Failed to get filtered watched collections for user: %s. Skipping.", uid)
-				return
+				log.Error().Err(err).Msgf("Failed to get filtered collections for user: %s. Skipping.", uid)
+				continue
 			}
-			log.Info().Msgf("Found %d filtered watched collections for user: %s in last %d days", len(filteredWatched), uid, daysSinceLastActive)
+			log.Info().Msgf("Found %d filtered collections for user: %s since %s", len(recentCollections), uid, lastActiveDay.Format(util.SubjectDateFormat))
 
-			if len(filteredWatched) > 0 {
-				svc.insertUserWithQueriedCollections(uid, filteredWatched)
+			if len(recentCollections) > 0 {
+				if err := svc.insertUserWithQueriedCollections(uid, recentCollections); err != nil {
+					log.Error().Err(err).Msgf("Failed to persist user: %s. Skipping.", uid)
+					continue
+				}
+			}
+			// the user was sighted collecting again, so an inactive user is active again, like after passing the regular update
+			if err := svc.konomiAccessor.MarkUserActive(uid); err != nil {
+				log.Error().Err(err).Msgf("Failed to mark user: %s active", uid)
+			}
+			// refresh the score, as existing users are not evaluated again
+			if qualityScore, err := svc.vipEvaluator.UserQualityScore(uid); err != nil {
+				log.Error().Err(err).Msgf("Failed to compute quality score for user: %s", uid)
+			} else if err := svc.konomiAccessor.UpdateUserQualityScore(uid, qualityScore); err != nil {
+				log.Error().Err(err).Msgf("Failed to update quality score for user: %s", uid)
 			}
 			persistedUserCnt++
 		}
@@ -63,22 +108,98 @@ func (svc *UserPersistingService) Persist(uids []string) {
 	log.Info().Msgf("In total, persisted %d users", persistedUserCnt)
 }
 
-func (svc *UserPersistingService) insertUserWithQueriedCollections(uid string, watchedCollections []model.Collection) {
-	log.Info().Msgf("Found %d watched collections for user: %s", len(watchedCollections), uid)
-	user, err := svc.getUser(uid)
+func (svc *UserPersistingService) updateBotFlag(uid string, flags []string) {
+	if len(flags) == 0 {
+		return
+	}
+	log.Info().Msgf("User %s is flagged as a possible bot: %s", uid, strings.Join(flags, "; "))
+	if err := svc.konomiAccessor.UpdateUserBotFlag(uid, strings.Join(flags, "; ")); err != nil {
+		log.Error().Err(err).Msgf("Failed to flag user: %s", uid)
+	}
+}
+
+func (svc *UserPersistingService) updateUserEvaluation(result helper.EvaluationResult) {
+	// already fetched to be persisted
+	filteredWatched, err := result.Source.RatedCollections(model.Watched)
 	if err != nil {
-		log.Error().Err(err).Msgf("Failed to get user: %s. Skipping...", uid)
+		log.Error().Err(err).Msgf("Failed to get filtered watched collections for user: %s", result.UserID)
 		return
 	}
+	evaluation, err := svc.vipEvaluator.UserEvaluation(result, len(filteredWatched))
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to measure tier of user: %s", result.UserID)
+		return
+	}
+	if err := svc.konomiAccessor.UpdateUserEvaluation(evaluation); err != nil {
+		log.Error().Err(err).Msgf("Failed to update evaluation of user: %s", result.UserID)
+	}
+}
+
+// recordEvaluation stores the evaluation in the candidate ledger, unless it did not complete
+func (svc *UserPersistingService) recordEvaluation(result helper.EvaluationResult) {
+	if result.Err != nil || len(result.Results) == 0 {
+		return
+	}
+	if err := svc.konomiAccessor.InsertCandidateEvaluation(result.ToCandidateEvaluation(time.Now())); err != nil {
+		log.Error().Err(err).Msgf("Failed to record evaluation of user: %s", result.UserID)
+	}
+}
+
+func (svc *UserPersistingService) updateQualityScore(result helper.EvaluationResult) {
+	qualityScore, err := svc.vipEvaluator.QualityScore(result)
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to compute quality score for user: %s", result.UserID)
+		return
+	}
+	if err := svc.konomiAccessor.UpdateUserQualityScore(result.UserID, qualityScore); err != nil {
+		log.Error().Err(err).Msgf("Failed to update quality score for user: %s", result.UserID)
+	}
+}
+
+// getSyncedCollections reuses the watched collections already queried during VIP evaluation
+// and fetches the collections of the other synced types
+func (svc *UserPersistingService) getSyncedCollections(uid string, source helper.UserDataSource) ([]model.Collection, error) {
+	collections := make([]model.Collection, 0)
+	otherTypes := make([]model.CollectionType, 0, len(svc.syncedCollectionTypes))
+	for _, ctype := range svc.syncedCollectionTypes {
+		if ctype == model.Watched {
+			watchedCollections, err := source.RatedCollections(model.Watched)
+			if err != nil {
+				return nil, err
+			}
+			collections = append(collections, watchedCollections...)
+		} else {
+			otherTypes = append(otherTypes, ctype)
+		}
+	}
+
+	otherCollections, err := helper.GetAnimeCollections(svc.bgmClient, svc.vipEvaluator.SubjectFilter(), uid, otherTypes, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	return append(collections, otherCollections...), nil
+}
+
+func (svc *UserPersistingService) insertUserWithQueriedCollections(uid string, collections []model.Collection) error {
+	log.Info().Msgf("Found %d collections for user: %s", len(collections), uid)
+	user, err := svc.getUser(uid)
+	if err != nil {
+		return err
+	}
 
 	insertUserErr := svc.konomiAccessor.InsertUser(user)
 	if insertUserErr == nil {
-		svc.konomiAccessor.BatchInsertCollection(watchedCollections, collectionInsertBatchSize)
+		svc.konomiAccessor.BatchInsertCollection(collections, collectionInsertBatchSize)
+		svc.konomiAccessor.BatchInsertCollectionDetail(model.DetailsOf(collections), collectionInsertBatchSize)
+		// comments and tags cleared since the last update leave an empty detail
+		if err := svc.konomiAccessor.DeleteCollectionDetails(model.EmptyDetailsOf(collections)); err != nil {
+			log.Error().Err(err).Msgf("Failed to delete cleared collection details of user: %s", uid)
+		}
 		log.Info().Msgf("Successfully persisted user: %s", uid)
 	} else {
-		log.Error().Err(insertUserErr).Msgf("Failed to persist user: %s. Skipping...", uid)
-		return
+		return fmt.Errorf("failed to insert user: %s (%w)", uid, insertUserErr)
 	}
+	return nil
 }
 
 func (svc *UserPersistingService) getUser(uid string) (model.User, error) {
@@ -1,78 +1,143 @@
 package service
 
 import (
-	"math"
-	"time"
-
 	dao "github.com/AlcEccentric/beck-mizuki/dao"
 	"github.com/AlcEccentric/beck-mizuki/helper"
 	model "github.com/AlcEccentric/beck-mizuki/model"
 	"github.com/AlcEccentric/beck-mizuki/model/job"
+	"github.com/AlcEccentric/beck-mizuki/util"
 	"github.com/rs/zerolog/log"
 )
 
 type UserUpdatingService struct {
-	bgmClient      *dao.BgmApiAccessor
-	konomiAccessor dao.KonomiAccessor
+	bgmClient             *dao.BgmApiAccessor
+	konomiAccessor        dao.KonomiAccessor
+	syncedCollectionTypes []model.CollectionType
+	vipEvaluator          *helper.VipEvaluator
 }
 
 func NewUserUpdatingService(
 	bgmClient *dao.BgmApiAccessor,
 	konomiAccessor dao.KonomiAccessor,
+	syncedCollectionTypes []model.CollectionType,
+	vipEvaluator *helper.VipEvaluator,
 ) *UserUpdatingService {
 	return &UserUpdatingService{
-		bgmClient:      bgmClient,
-		konomiAccessor: konomiAccessor,
+		bgmClient:             bgmClient,
+		konomiAccessor:        konomiAccessor,
+		syncedCollectionTypes: syncedCollectionTypes,
+		vipEvaluator:          vipEvaluator,
 	}
 }
 
 func (svc *UserUpdatingService) GetUserUpdater() func(in *job.RegularUpdateOrchJob) (*job.RegularUpdateOrchJob, error) {
 	return func(in *job.RegularUpdateOrchJob) (*job.RegularUpdateOrchJob, error) {
-		activeUserIds := make([]string, 0)
+		activeResults := make([]helper.EvaluationResult, 0)
 		inactiveUserIds := make([]string, 0)
+		results := make([]helper.EvaluationResult, 0, len(in.UserIds))
 		log.Info().Msgf("Trying to update %d users", len(in.UserIds))
 		for _, uid := range in.UserIds {
-			// Get raw count
-			rawWatchedCount, err := svc.bgmClient.GetCollectionCount(uid, model.Watched, model.Anime)
-			if err != nil {
-				log.Error().Err(err).Msgf("Failed to get raw watched count for user: %s. Skipping...", uid)
+			// check if user is still active (other check will always succeed for existing user, so we only check recent activity)
+			result := svc.vipEvaluator.IsActive(uid)
+			if result.Err != nil {
+				log.Error().Err(result.Err).Msgf("Failed to check activity of user: %s. Skipping...", uid)
 				continue
 			}
-			// check if user is still active (other check will always succeed for existing user, so we only check recent activity)
-			isActive := helper.IsActive(svc.bgmClient, uid, rawWatchedCount)
 
-			if isActive {
-				log.Debug().Msgf("User %s is active", uid)
-				activeUserIds = append(activeUserIds, uid)
+			results = append(results, result)
+			log.Debug().Msg(result.String())
+			if result.Passed {
+				activeResults = append(activeResults, result)
 			} else {
-				log.Debug().Msgf("User %s is not active", uid)
 				inactiveUserIds = append(inactiveUserIds, uid)
 			}
 		}
 
-		svc.updateActiveUsers(activeUserIds)
+		svc.updateActiveUsers(activeResults)
+		// evaluations are saved once new collections are persisted, so that the filtered watched count includes them
+		svc.updateUserEvaluations(results)
 		in.InactiveUserIds = inactiveUserIds
 		return in, nil
 	}
 }
 
-func (svc *UserUpdatingService) updateActiveUsers(uids []string) {
-	for _, uid := range uids {
+func (svc *UserUpdatingService) updateUserEvaluations(results []helper.EvaluationResult) {
+	for _, result := range results {
+		// stored watched collections are the filtered ones
+		filteredWatchedCount, err := svc.konomiAccessor.GetWatchedCollectionCount(result.UserID)
+		if err != nil {
+			log.Error().Err(err).Msgf("Failed to count watched collections of user: %s", result.UserID)
+			continue
+		}
+		evaluation, err := svc.vipEvaluator.UserEvaluation(result, filteredWatchedCount)
+		if err != nil {
+			log.Error().Err(err).Msgf("Failed to measure tier of user: %s", result.UserID)
+			continue
+		}
+		if err := svc.konomiAccessor.UpdateUserEvaluation(evaluation); err != nil {
+			log.Error().Err(err).Msgf("Failed to update evaluation of user: %s", result.UserID)
+		}
+	}
+}
+
+// updateActiveUsers persists the new collections of active users
+// Collections rejected by the bot detectors are skipped and only flag the user, as the user is still active and must not count as failing the activity check
+func (svc *UserUpdatingService) updateActiveUsers(results []helper.EvaluationResult) {
+	for _, result := range results {
+		uid := result.UserID
 		user, getUserErr := svc.bgmClient.GetUser(uid)
 		if getUserErr != nil {
 			log.Error().Err(getUserErr).Msgf("Failed to get user: %s. Skipping...", uid)
 			continue
 		}
-		daysSinceLastActive := math.Ceil(time.Since(user.LastActiveTime).Abs().Hours() / 24.0)
-		collections, getCollectionsErr := svc.bgmClient.GetRecentCollections(uid, model.Watched, model.Anime, helper.AnimeFilter, int(daysSinceLastActive))
+		lastActiveDay := util.StartOfDay(user.LastActiveTime, svc.vipEvaluator.CalendarLocation())
+		collections, getCollectionsErr := helper.GetAnimeCollections(svc.bgmClient, svc.vipEvaluator.SubjectFilter(), uid, svc.syncedCollectionTypes, lastActiveDay)
 		if getCollectionsErr != nil {
-			log.Error().Err(getCollectionsErr).Msgf("Failed to get recent watched collections for user: %s. Skipping...", uid)
+			log.Error().Err(getCollectionsErr).Msgf("Failed to get recent collections for user: %s. Skipping...", uid)
+			continue
+		}
+
+		botResult := svc.vipEvaluator.DetectBots(uid, collections)
+		if botResult.Err == nil && !botResult.Passed {
+			log.Info().Msgf("New collections of user %s are excluded as a possible bot: %s", uid, botResult.String())
+		}
+		// the detectors only see the new collections, so they can add flags but never clear the ones found on the full history
+		for _, ruleResult := range botResult.Results {
+			if !ruleResult.Flagged && (ruleResult.Passed || ruleResult.Err != nil) {
+				continue
+			}
+			if err := svc.konomiAccessor.AppendUserBotFlag(uid, ruleResult.Rule, ruleResult.Reason); err != nil {
+				log.Error().Err(err).Msgf("Failed to update bot flag of user: %s", uid)
+			}
+		}
+		if botResult.FailedRule != "" {
 			continue
 		}
 
 		svc.konomiAccessor.InsertUser(user)
 		svc.konomiAccessor.BatchInsertCollection(collections, 100)
+		svc.konomiAccessor.BatchInsertCollectionDetail(model.DetailsOf(collections), 100)
+		// comments and tags cleared since the last update leave an empty detail
+		if err := svc.konomiAccessor.DeleteCollectionDetails(model.EmptyDetailsOf(collections)); err != nil {
+			log.Error().Err(err).Msgf("Failed to delete cleared collection details of user: %s", uid)
+		}
+		// reactivate users that failed previous checks
+		if err := svc.konomiAccessor.MarkUserActive(uid); err != nil {
+			log.Error().Err(err).Msgf("Failed to mark user: %s active", uid)
+		}
+		svc.updateQualityScore(result)
 		log.Info().Msgf("Updated user: %s with %d collections", uid, len(collections))
 	}
+}
 
+// updateQualityScore refreshes the score with the collections the activity check already fetched
+func (svc *UserUpdatingService) updateQualityScore(result helper.EvaluationResult) {
+	qualityScore, err := svc.vipEvaluator.QualityScore(result)
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to compute quality score for user: %s", result.UserID)
+		return
+	}
+	if err := svc.konomiAccessor.UpdateUserQualityScore(result.UserID, qualityScore); err != nil {
+		log.Error().Err(err).Msgf("Failed to update quality score for user: %s", result.UserID)
+	}
 }
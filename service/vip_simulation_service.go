@@ -0,0 +1,144 @@
+package service
+
+import (
+	"time"
+
+	dao "github.com/AlcEccentric/beck-mizuki/dao"
+	"github.com/AlcEccentric/beck-mizuki/helper"
+	model "github.com/AlcEccentric/beck-mizuki/model"
+)
+
+// SimulationReport counts the users that would have passed a set of rules at a date
+type SimulationReport struct {
+	ThresholdSet string
+	AsOf         time.Time
+	Rules        []helper.Rule
+	Evaluated    int
+	Errored      int
+	PassedByRule map[string]int
+	Vips         map[string]struct{}
+	// the activity rule is checked against whether users still watched something in the activity check window after AsOf
+	// only counted when the follow-up period is over
+	ActivityRule            helper.Rule
+	Thresholds              helper.VipThresholds
+	PredictedActive         int
+	PredictedActiveStayed   int
+	PredictedInactive       int
+	PredictedInactiveStayed int
+}
+
+func NewSimulationReport(thresholdSet string, asOf time.Time, rules []helper.Rule, activityRule helper.Rule, thresholds helper.VipThresholds) *SimulationReport {
+	return &SimulationReport{
+		ThresholdSet: thresholdSet,
+		AsOf:         asOf,
+		Rules:        rules,
+		ActivityRule: activityRule,
+		Thresholds:   thresholds,
+		PassedByRule: make(map[string]int, len(rules)),
+		Vips:         make(map[string]struct{}),
+	}
+}
+
+// Overlap returns the number of simulated VIPs that are VIPs today, and the numbers of VIPs only found on either side
+func (report *SimulationReport) Overlap(todayVips map[string]struct{}) (both, simulatedOnly, todayOnly int) {
+	for uid := range report.Vips {
+		if _, ok := todayVips[uid]; ok {
+			both++
+		} else {
+			simulatedOnly++
+		}
+	}
+	return both, simulatedOnly, len(todayVips) - both
+}
+
+// VipSimulationService replays the stored collection histories against alternative VIP rules
+type VipSimulationService struct {
+	konomiAccessor dao.KonomiAccessor
+}
+
+func NewVipSimulationService(konomiAccessor dao.KonomiAccessor) *VipSimulationService {
+	return &VipSimulationService{
+		konomiAccessor: konomiAccessor,
+	}
+}
+
+// GetHistory returns the stored collections of a persisted user, or the scraped collections of a candidate that was never persisted
+func (svc *VipSimulationService) GetHistory(uid string) ([]model.Collection, error) {
+	collections, err := svc.konomiAccessor.GetCollectionsByUid(uid)
+	if err != nil || len(collections) > 0 {
+		return collections, err
+	}
+
+	scrapedCollections, err := svc.konomiAccessor.GetScrapedCollectionsByUid(uid)
+	if err != nil {
+		return nil, err
+	}
+	collections = make([]model.Collection, 0, len(scrapedCollections))
+	for _, scrapedCollection := range scrapedCollections {
+		if scrapedCollection.CollectionType != 0 {
+			collections = append(collections, scrapedCollection.ToCollection())
+		}
+	}
+	return collections, nil
+}
+
+// Simulate evaluates all the rules of each report on the history of the user, so that each rule is counted even after another one failed
+func (svc *VipSimulationService) Simulate(uid string, history []model.Collection, reports []*SimulationReport) {
+	for _, report := range reports {
+		result := helper.EvaluateRules(report.Rules, &helper.EvaluationContext{
+			UserID:      uid,
+			SubjectType: model.Anime,
+			Source:      helper.NewHistoryUserDataSource(history, model.Anime, report.AsOf, report.Thresholds.CalendarLocation),
+		}, false)
+
+		report.Evaluated++
+		if result.Err != nil {
+			report.Errored++
+			continue
+		}
+		for _, ruleResult := range result.Results {
+			if ruleResult.Passed {
+				report.PassedByRule[ruleResult.Rule]++
+			}
+		}
+		if result.Passed {
+			report.Vips[uid] = struct{}{}
+		}
+		svc.predictActivity(uid, history, report)
+	}
+}
+
+// predictActivity counts if the activity rule told right whether the user would keep watching
+func (svc *VipSimulationService) predictActivity(uid string, history []model.Collection, report *SimulationReport) {
+	followUpEnd := report.AsOf.AddDate(0, 0, report.Thresholds.ActivityCheckDays)
+	if followUpEnd.After(time.Now()) {
+		return
+	}
+	activityResult := report.ActivityRule.Evaluate(&helper.EvaluationContext{
+		UserID:      uid,
+		SubjectType: model.Anime,
+		Source:      helper.NewHistoryUserDataSource(history, model.Anime, report.AsOf, report.Thresholds.CalendarLocation),
+	})
+	if activityResult.Err != nil {
+		return
+	}
+
+	stayed := false
+	for _, collection := range history {
+		if model.CollectionType(collection.CollectionType) == model.Watched && collection.CollectedTime.After(report.AsOf) && !collection.CollectedTime.After(followUpEnd) {
+			stayed = true
+			break
+		}
+	}
+	if activityResult.Passed {
+		report.PredictedActive++
+		if stayed {
+			report.PredictedActiveStayed++
+		}
+	} else {
+		report.PredictedInactive++
+		if stayed {
+			report.PredictedInactiveStayed++
+		}
+	}
+}
@@ -12,15 +12,45 @@ const (
 	T2IntervalDays              = 20
 	T3IntervalDays              = 30
 	NonWatchedIntervalTolerance = 3
-	MinFilteredWatchedCnt       = 300
-	SubjectMinCollectionCnt     = 100
-	MaxWatchedAnimeCount        = 3000
+	// activity, see helper.ActivityModel
+	ActivityModel                = "bucket" // bucket, decay or poisson
+	ActivityHalfLifeDays         = 30.0
+	MinDecayedActivityScore      = 3.0 // e.g. 3 watched today, or 6 watched a month ago
+	MinPoissonWatchProbability   = 0.8
+	MinPoissonSilenceProbability = 0.05
+	MinFilteredWatchedCnt        = 300
+	MaxWatchedAnimeCount         = 3000
+	MaxWatchedPerDay             = 10
+	SustainedWatchedPerDay       = 1.0 // what a dedicated user keeps up over months, bounds how much a failed count may have grown
+	CandidateCooldownInDays      = 360 // rejected candidates are not evaluated again for 3 cold starts unless their failing metric may have changed
+	// rules evaluated in order for each subject type, see helper.NewRule
+	VipRules = "anime=quality_score,burst_import,uniform_rating,watch_velocity"
+	// bot detection, see helper.BotDetectionAction
+	BotDetectionAction    = "exclude" // flag or exclude
+	BurstWindowMinutes    = 10
+	BurstMaxCollections   = 30
+	UniformRatingMinRated = 20
+	UniformRatingMaxShare = 0.9
+	VelocityWindowDays    = 7
+	// quality score, see helper.QualityModel
+	MinQualityScore             = 0.5
+	QualityWatchedCountWeight   = 0.3
+	QualityHistoryLengthWeight  = 0.15
+	QualityRegularityWeight     = 0.2
+	QualityRatingCoverageWeight = 0.2
+	QualityRatingVarianceWeight = 0.15
+	QualityFullWatchedCnt       = T3WatchedCnt
+	QualityFullHistoryDays      = 3 * MinOldestWatchedAgeInDays
+	QualityFullRatingStdDev     = 2.0
+	SyncedCollectionTypes       = "ToWatch,Watched,Watching,Postponed,Discarded" // comma separated model.CollectionType names
 
 	// various data format
 	SubjectDateFormat           = "2006-01-02"
 	LaunchDateFormat            = "2006-01-02"
 	CollecttedTimeFormat        = "2006-01-02T15:04:05-07:00"
 	WebsiteCollectionTimeFormat = "2006-1-2 15:04"
+	SiteTimeZone                = "Asia/Shanghai" // website times are shown in this zone without an offset
+	CalendarTimeZone            = "Asia/Shanghai" // activity windows and scraping horizons are counted in calendar days of this zone
 
 	// API parameters
 	PageLimit                  = 50
@@ -30,20 +60,53 @@ const (
 	APICallAdditionalDelayInMs = 500
 
 	// Scraper parameters
-	SubjectCollectionUrlFormat = "https://bangumi.tv/subject/%s/collections?page=%d"
-	ScraperBaseDelayInS        = 2
-	ScraperAdditionalDelayInS  = 2
+	// paths are requested on the current mirror, see scraper.MirrorPool
+	ScraperMirrors               = "bangumi.tv,bgm.tv,chii.in" // comma separated, the first one is preferred
+	MirrorFailoverErrorThreshold = 5
+	SubjectCollectionPathFormat  = "/subject/%s/collections?page=%d"
+	SubjectDoingsPathFormat      = "/subject/%s/doings?page=%d"
+	SubjectWishesPathFormat      = "/subject/%s/wishes?page=%d"
+	GroupMembersPathFormat       = "/group/%s/members?page=%d"
+	UserFriendsPathFormat        = "/user/%s/friends?page=%d"
+	ScraperBaseDelayInS          = 2
+	ScraperAdditionalDelayInS    = 2
+	ScraperCheckpointPath        = "beck_mizuki_checkpoint.json"
+	ScraperCheckpointIntervalInS = 60
+	SubjectPageParserVersion     = "v1"
+	ParseFailureRateThreshold    = 0.2
+	ParseFailureMinPages         = 20
+
+	// Proxy parameters
+	ProxyAssignment             = "round_robin" // round_robin or sticky
+	ProxyMaxConsecutiveErrors   = 3
+	ProxyThrottleBackoffInS     = 30 // doubled on each rate limited response in a row
+	ProxyMaxThrottleBackoffInS  = 600
+	ProxyHealthCheckUrl         = "https://bangumi.tv/robots.txt"
+	ProxyHealthCheckIntervalInS = 60
 
 	// Orchestration parameters
 	// Cold start
 	ColdStartIntervalInDays                  = 120
 	NumOfSubjectRetrievers                   = 30
 	NumOfUserIdRetrievers                    = 1 // could be more than 1 but should be cautious as it will incur high pressure on the target website
-	NumOfUserIdMergers                       = 1 // must be one as the ids will be merged into a map and map is not thread safe
+	NumOfUserIdMergers                       = 4 // sightings are merged by the database, see service.CandidateStore
+	CandidateReadBatchSize                   = 1000
 	UserIdRetrieverCoolDownSecondsPerSubject = 3
+	DiscoverySources                         = "subject_collections" // comma separated, see param.DiscoveryParams
+	SeedUidBatchSize                         = 500
+	SnowballMaxDepth                         = 2
+	SnowballMaxUids                          = 5000
 	// Regular update
-	RegularUpdateIntervalInDays = 30
-	NumOfUserIDReaders          = 5
-	NumOfUserUpdaters           = 5
-	NumOfUserCleaners           = 5
+	RegularUpdateIntervalInDays  = 30
+	NumOfUserIDReaders           = 5
+	NumOfUserUpdaters            = 5
+	NumOfUserCleaners            = 5
+	InactiveStrikesBeforeRemoval = 3
+	// Subject relation sync
+	RelationRefreshIntervalInDays = 180
+	SubjectSyncBatchSize          = 50
+	NumOfSubjectRelationSyncers   = 1 // relations are fetched from API, keep it low to be polite
+	// Subject staff sync
+	StaffRefreshIntervalInDays = 180
+	NumOfSubjectStaffSyncers   = 1 // staff are fetched from API, keep it low to be polite
 )
@@ -0,0 +1,39 @@
+package util
+
+import (
+	"time"
+	_ "time/tzdata" // zones are resolved even where the system has no zone database
+)
+
+// SiteLocation is the zone the website shows its times in, as they carry no offset
+var SiteLocation = mustLoadLocation(SiteTimeZone)
+
+// DefaultCalendarLocation is the zone calendar days are counted in unless configured otherwise
+var DefaultCalendarLocation = mustLoadLocation(CalendarTimeZone)
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// StartOfDay returns the start of the calendar day of t in loc
+func StartOfDay(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// CalendarWindowStart returns the start of a window of windowInDays calendar days in loc, ending with the day of now
+func CalendarWindowStart(now time.Time, windowInDays int, loc *time.Location) time.Time {
+	return StartOfDay(now, loc).AddDate(0, 0, 1-windowInDays)
+}
+
+// CalendarDaysBetween returns the number of calendar days in loc from the day of from to the day of to
+func CalendarDaysBetween(from, to time.Time, loc *time.Location) int {
+	fromYear, fromMonth, fromDay := from.In(loc).Date()
+	toYear, toMonth, toDay := to.In(loc).Date()
+	// dates are compared in UTC so that a daylight saving change does not make a day shorter
+	return int(time.Date(toYear, toMonth, toDay, 0, 0, 0, 0, time.UTC).Sub(time.Date(fromYear, fromMonth, fromDay, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+}